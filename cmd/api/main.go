@@ -1,94 +1,119 @@
 package main
 
 import (
-	"log"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	_ "time/tzdata" // organization timezones are validated with time.LoadLocation
 
 	"github.com/atavada/project-management-saas/internal/config"
 	"github.com/atavada/project-management-saas/internal/database"
-	"github.com/atavada/project-management-saas/internal/handlers"
-	"github.com/atavada/project-management-saas/internal/repository"
-	"github.com/atavada/project-management-saas/internal/routes"
+	"github.com/atavada/project-management-saas/internal/logging"
+	"github.com/atavada/project-management-saas/internal/metrics"
+	"github.com/atavada/project-management-saas/internal/middleware"
+	"github.com/atavada/project-management-saas/internal/server"
+	"github.com/atavada/project-management-saas/internal/services"
+	"github.com/atavada/project-management-saas/internal/tracing"
 	"github.com/gofiber/fiber/v3"
-	"github.com/gofiber/fiber/v3/middleware/cors"
-	"github.com/gofiber/fiber/v3/middleware/logger"
-	"github.com/gofiber/fiber/v3/middleware/recover"
 )
 
+// version is set at build time with -ldflags "-X main.version=..."
+var version = "dev"
+
 func main() {
+	slog.SetDefault(logging.New(os.Stdout, slog.LevelInfo))
+
+	if err := run(); err != nil {
+		slog.Error("Server failed", "error", err)
+		os.Exit(1)
+	}
+}
+
+// run starts the server and blocks until it fails or receives SIGINT/SIGTERM.
+// It returns instead of exiting so deferred cleanup always runs
+func run() error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	cfg, err := config.Load()
 	if err != nil {
-		log.Fatalf("Failed to load config: %v", err)
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	slog.SetDefault(logging.New(os.Stdout, cfg.SlogLevel()))
+
+	// Install the tracer provider before the pools, their query tracer uses it
+	shutdownTracing, err := tracing.Setup(ctx, cfg.Tracing(version))
+	if err != nil {
+		return fmt.Errorf("failed to set up tracing: %w", err)
 	}
 
 	// Connect to DB
-	db, err := database.New(cfg.DatabaseURL)
+	db, err := database.New(cfg.Database())
 	if err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
+		return fmt.Errorf("failed to connect to database: %w", err)
 	}
 	defer db.Close()
 
-	// Initialize repo
-	userRepo := repository.NewUserRepository(db)
-	orgRepo := repository.NewOrganizationRepository(db)
-	memberRepo := repository.NewOrganizationMemberRepository(db)
-
-	// Initialize handlers
-	webhookHandler := handlers.NewWebhookHandler(
-		userRepo,
-		orgRepo,
-		memberRepo,
-		cfg.ClerkWebhookSecret,
-	)
-	userHandler := handlers.NewUserHandler(userRepo)
-	orgHandler := handlers.NewOrganizationHandler(userRepo, orgRepo, memberRepo)
-
-	allHandlers := &routes.Handlers{
-		Webhook: webhookHandler,
-		User: userHandler,
-		Organization: orgHandler,
+	migrator, err := database.NewMigrator(db)
+	if err != nil {
+		return fmt.Errorf("failed to load migrations: %w", err)
+	}
+
+	// Apply pending migrations, the advisory lock keeps replicas from racing
+	if cfg.AutoMigrate {
+		if err := migrator.Up(ctx, 0); err != nil {
+			return fmt.Errorf("failed to run migrations: %w", err)
+		}
 	}
 
-	// Create Fiber app
-	app := fiber.New(fiber.Config{
-		ErrorHandler: customErrorHandler,
-	})
-
-	// Middleware
-	app.Use(recover.New())
-	app.Use(logger.New(logger.Config{
-		Format: "[${time}] ${status} - ${method} ${path} (${latency})\n",
-	}))
-	app.Use(cors.New(cors.Config{
-		AllowOrigins: []string{cfg.AllowedOrigins},
-		AllowHeaders: []string{"Origin", "Content-Type", "Accept", "Authorization"},
-		AllowMethods: []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowCredentials: true,
-	}))
-
-	// Setup routes
-	routes.SetupRoutes(app, allHandlers, cfg.ClerkSecretKey)
+	if err := metrics.RegisterPool(db); err != nil {
+		return fmt.Errorf("failed to register pool metrics: %w", err)
+	}
 
-	// Start server
-	port := cfg.Port
-	if port == "" {
-		port = "8080"
+	srv := server.New(cfg, db, migrator, middleware.NewClerkVerifier(cfg.ClerkSecretKey), services.NewClerkUsers(cfg.ClerkSecretKey), version)
+	app := srv.App
+
+	// Exports a previous run was building when it went down would stay pending
+	if failed, err := srv.FailStaleExports(ctx); err != nil {
+		slog.Error("Error failing stale data exports", "error", err)
+	} else if failed > 0 {
+		slog.Warn("Failed stale data exports", "count", failed)
 	}
 
-	log.Printf("Server starting on port %s", port)
-	log.Fatal(app.Listen(":" + port))
-}
+	// Start server
+	serverErr := make(chan error, 1)
+	go func() {
+		slog.Info("Server starting", "port", cfg.Port, "version", version, "env", cfg.Environment)
+		serverErr <- app.Listen(fmt.Sprintf(":%d", cfg.Port), fiber.ListenConfig{
+			DisableStartupMessage: true,
+		})
+	}()
 
-func customErrorHandler(c fiber.Ctx, err error) error {
-	code := fiber.StatusInternalServerError
-	message := "Internal Server Error"
+	select {
+	case err := <-serverErr:
+		return fmt.Errorf("server error: %w", err)
+	case <-ctx.Done():
+	}
+
+	// Stop accepting connections and drain in-flight requests, then stop the
+	// background workers before the deferred db.Close runs
+	slog.Info("Shutting down", "drain_timeout", cfg.ShutdownTimeout.String())
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
 
-	if e, ok := err.(*fiber.Error); ok {
-		code = e.Code
-		message = e.Message
+	if err := app.ShutdownWithContext(shutdownCtx); err != nil {
+		slog.Error("Error shutting down server", "error", err)
+	}
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		slog.Error("Error stopping export workers", "error", err)
+	}
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		slog.Error("Error flushing traces", "error", err)
 	}
 
-	return c.Status(code).JSON(fiber.Map{
-		"error": message,
-		"message": err.Error(),
-	})
-}
\ No newline at end of file
+	slog.Info("Server stopped")
+	return nil
+}
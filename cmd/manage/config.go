@@ -0,0 +1,23 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/atavada/project-management-saas/internal/config"
+)
+
+func runConfig(ctx context.Context, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, field := range cfg.Fields() {
+		fmt.Fprintf(w, "%s\t%s\n", field[0], field[1])
+	}
+	return w.Flush()
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+
+	"github.com/atavada/project-management-saas/internal/repository"
+	"github.com/atavada/project-management-saas/internal/services"
+	"github.com/google/uuid"
+)
+
+func runEraseUser(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("erase-user", flag.ExitOnError)
+	userIDFlag := fs.String("user", "", "ID of the user to anonymize (required)")
+	confirm := fs.Bool("yes", false, "confirm the erasure, it cannot be undone")
+	fs.Parse(args)
+
+	if *userIDFlag == "" {
+		return errors.New("-user is required")
+	}
+	userID, err := uuid.Parse(*userIDFlag)
+	if err != nil {
+		return fmt.Errorf("invalid user ID: %w", err)
+	}
+	if !*confirm {
+		return errors.New("erasure cannot be undone, pass -yes to confirm")
+	}
+
+	cfg, db, err := connect()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	users := auditor(db).Users(repository.NewUserRepository(db), repository.NewOrganizationMemberRepository(db))
+	erasure := services.NewErasureService(users, services.NewClerkUsers(cfg.ClerkSecretKey))
+	user, err := erasure.EraseUser(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", userID, err)
+	}
+
+	log.Printf("User anonymized: %s", user.ID)
+	return nil
+}
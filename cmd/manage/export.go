@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/atavada/project-management-saas/internal/repository"
+	"github.com/atavada/project-management-saas/internal/services"
+	"github.com/google/uuid"
+)
+
+func runExportUser(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("export-user", flag.ExitOnError)
+	userIDFlag := fs.String("user", "", "ID of the user to export (required)")
+	out := fs.String("out", "", "output file, defaults to data-export-<user>.zip")
+	fs.Parse(args)
+
+	if *userIDFlag == "" {
+		return errors.New("-user is required")
+	}
+	userID, err := uuid.Parse(*userIDFlag)
+	if err != nil {
+		return fmt.Errorf("invalid user ID: %w", err)
+	}
+	if *out == "" {
+		*out = fmt.Sprintf("data-export-%s.zip", userID)
+	}
+
+	_, db, err := connect()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	exportService := services.NewExportService(
+		repository.NewUserRepository(db),
+		repository.NewUserPreferencesRepository(db),
+		repository.NewOrganizationRepository(db),
+		repository.NewOrganizationMemberRepository(db),
+		repository.NewTaskRepository(db),
+		repository.NewDataExportRepository(db),
+		repository.NewAuditEventRepository(db),
+		repository.NewTaskActivityRepository(db),
+	)
+
+	archive, err := exportService.BuildArchive(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(*out, archive, 0o600); err != nil {
+		return fmt.Errorf("error writing archive: %w", err)
+	}
+
+	log.Printf("Data export written to %s", *out)
+	return nil
+}
@@ -0,0 +1,88 @@
+// Command manage runs operational tasks against the same config, database and
+// repositories as the API server.
+//
+// Usage:
+//
+//	manage <command> [flags]
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/atavada/project-management-saas/internal/audit"
+	"github.com/atavada/project-management-saas/internal/config"
+	"github.com/atavada/project-management-saas/internal/database"
+	"github.com/atavada/project-management-saas/internal/repository"
+	"github.com/atavada/project-management-saas/internal/services"
+)
+
+type command struct {
+	name    string
+	summary string
+	run     func(ctx context.Context, args []string) error
+}
+
+var commands = []command{
+	{"migrate", "Apply or revert the embedded database migrations", runMigrate},
+	{"seed", "Fill a development database with sample data", runSeed},
+	{"promote-owner", "Make a user an owner of an organization", runPromoteOwner},
+	{"reconcile", "Sync users, organizations and memberships from Clerk", runReconcile},
+	{"export-user", "Write a ZIP archive of everything stored about a user", runExportUser},
+	{"erase-user", "Delete a user in Clerk and anonymize their personal data, keeping their tasks", runEraseUser},
+	{"config", "Print the effective configuration with secrets redacted", runConfig},
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	name := os.Args[1]
+	for _, cmd := range commands {
+		if cmd.name == name {
+			// Changes made by commands are audited as the manage service account
+			ctx := audit.WithActor(context.Background(), audit.ServiceAccount("manage"))
+			if err := cmd.run(ctx, os.Args[2:]); err != nil {
+				log.Fatalf("%s: %v", name, err)
+			}
+			return
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "Unknown command: %s\n\n", name)
+	usage()
+	os.Exit(2)
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: manage <command> [flags]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-14s %s\n", cmd.name, cmd.summary)
+	}
+}
+
+// connect loads the config and opens the database pool
+func connect() (*config.Config, *database.DB, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	db, err := database.New(cfg.Database())
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	return cfg, db, nil
+}
+
+// auditor records the changes commands make through the repositories it wraps
+func auditor(db *database.DB) *services.AuditService {
+	return services.NewAuditService(repository.NewAuditEventRepository(db), repository.NewUserRepository(db), db)
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"strconv"
+
+	"github.com/atavada/project-management-saas/internal/database"
+)
+
+const migrateUsage = "usage: migrate up [N] | down [N] | down all -yes | status | goto VERSION | force VERSION"
+
+func runMigrate(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	_, db, err := connect()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	migrator, err := database.NewMigrator(db)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "up":
+		steps, err := optionalInt(args[1:], 0)
+		if err != nil {
+			return err
+		}
+		return migrator.Up(ctx, steps)
+	case "down":
+		steps, err := downSteps(args[1:])
+		if err != nil {
+			return err
+		}
+		return migrator.Down(ctx, steps)
+	case "goto", "force":
+		if len(args) != 2 {
+			return errors.New(migrateUsage)
+		}
+		version, err := strconv.ParseUint(args[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid version: %w", err)
+		}
+		if args[0] == "force" {
+			return migrator.Force(ctx, uint(version))
+		}
+		return migrator.Goto(ctx, uint(version))
+	case "status":
+		status, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Version: %d (latest %d)\n", status.Version, status.Latest)
+		if status.Dirty {
+			fmt.Println("Dirty:   yes, run force once the schema has been fixed")
+		}
+		if len(status.Pending) == 0 {
+			fmt.Println("Pending: none")
+		}
+		for _, m := range status.Pending {
+			fmt.Printf("Pending: %06d_%s\n", m.Version, m.Name)
+		}
+		return nil
+	default:
+		return errors.New(migrateUsage)
+	}
+}
+
+func optionalInt(args []string, fallback int) (int, error) {
+	if len(args) == 0 {
+		return fallback, nil
+	}
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid step count: %s", args[0])
+	}
+	return n, nil
+}
+
+// downSteps reads the arguments of migrate down. Reverting everything by
+// accident drops all data, so it defaults to one step and the Migrator's 0 for
+// all is only reached with an explicit "all -yes"
+func downSteps(args []string) (int, error) {
+	fs := flag.NewFlagSet("migrate down", flag.ContinueOnError)
+	confirm := fs.Bool("yes", false, "confirm reverting every migration, it drops all data")
+	if err := fs.Parse(args); err != nil {
+		return 0, errors.New(migrateUsage)
+	}
+
+	// Accept the flag after the step count too
+	target := fs.Arg(0)
+	if fs.NArg() > 0 {
+		if err := fs.Parse(fs.Args()[1:]); err != nil || fs.NArg() > 0 {
+			return 0, errors.New(migrateUsage)
+		}
+	}
+
+	switch target {
+	case "":
+		return 1, nil
+	case "all":
+		if !*confirm {
+			return 0, errors.New("reverting every migration drops all data, pass -yes to confirm")
+		}
+		return 0, nil
+	}
+
+	n, err := strconv.Atoi(target)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("invalid step count: %s", target)
+	}
+	return n, nil
+}
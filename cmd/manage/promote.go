@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+
+	"github.com/atavada/project-management-saas/internal/models"
+	"github.com/atavada/project-management-saas/internal/repository"
+	"github.com/google/uuid"
+)
+
+func runPromoteOwner(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("promote-owner", flag.ExitOnError)
+	orgFlag := fs.String("org", "", "organization UUID or slug (required)")
+	userFlag := fs.String("user", "", "user UUID or Clerk user ID (required)")
+	fs.Parse(args)
+
+	if *orgFlag == "" || *userFlag == "" {
+		return errors.New("-org and -user are required")
+	}
+
+	_, db, err := connect()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	userRepo := repository.NewUserRepository(db)
+	orgRepo := repository.NewOrganizationRepository(db)
+	memberRepo := auditor(db).Members(repository.NewOrganizationMemberRepository(db))
+
+	var org *models.Organization
+	if id, err := uuid.Parse(*orgFlag); err == nil {
+		org, err = orgRepo.GetByID(ctx, id)
+		if err != nil {
+			return fmt.Errorf("%s: %w", *orgFlag, err)
+		}
+	} else {
+		org, err = orgRepo.GetBySlug(ctx, *orgFlag)
+		if err != nil {
+			return fmt.Errorf("%s: %w", *orgFlag, err)
+		}
+	}
+
+	var user *models.User
+	if id, err := uuid.Parse(*userFlag); err == nil {
+		user, err = userRepo.GetByID(ctx, id)
+		if err != nil {
+			return fmt.Errorf("%s: %w", *userFlag, err)
+		}
+	} else {
+		user, err = userRepo.GetByClerkID(ctx, *userFlag)
+		if err != nil {
+			return fmt.Errorf("%s: %w", *userFlag, err)
+		}
+	}
+
+	if err := memberRepo.SetRole(ctx, org.ID, user.ID, models.RoleOwner); err != nil {
+		return err
+	}
+
+	log.Printf("%s is now an owner of %s", user.ID, org.Slug)
+	return nil
+}
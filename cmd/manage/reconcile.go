@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/atavada/project-management-saas/internal/repository"
+	"github.com/atavada/project-management-saas/internal/services"
+)
+
+func runReconcile(ctx context.Context, args []string) error {
+	cfg, db, err := connect()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if cfg.ClerkSecretKey == "" {
+		return errors.New("CLERK_SECRET_KEY is required")
+	}
+
+	audit := auditor(db)
+	reconciler := services.NewClerkReconciler(
+		cfg.ClerkSecretKey,
+		audit.Users(repository.NewUserRepository(db), repository.NewOrganizationMemberRepository(db)),
+		audit.Organizations(repository.NewOrganizationRepository(db)),
+		audit.Members(repository.NewOrganizationMemberRepository(db)),
+		db,
+	)
+
+	result, err := reconciler.Reconcile(ctx)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Reconciled %d users, %d organizations, %d memberships (%d removed)",
+		result.Users, result.Organizations, result.Memberships, result.MembershipsRemoved)
+	return nil
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+
+	"github.com/atavada/project-management-saas/internal/config"
+	"github.com/atavada/project-management-saas/internal/repository"
+	"github.com/atavada/project-management-saas/internal/seed"
+)
+
+func runSeed(ctx context.Context, args []string) error {
+	opts := seed.DefaultOptions()
+
+	fs := flag.NewFlagSet("seed", flag.ExitOnError)
+	fs.IntVar(&opts.Organizations, "orgs", opts.Organizations, "number of organizations")
+	fs.IntVar(&opts.MembersPerOrg, "members", opts.MembersPerOrg, "members per organization")
+	fs.IntVar(&opts.ProjectsPerStatus, "projects", opts.ProjectsPerStatus, "projects per project status in each organization")
+	fs.IntVar(&opts.TasksPerProject, "tasks", opts.TasksPerProject, "tasks per project")
+	fs.Int64Var(&opts.Seed, "seed", opts.Seed, "random seed, the same seed produces the same dataset")
+	fs.Parse(args)
+
+	cfg, db, err := connect()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if cfg.Environment == config.Production {
+		return errors.New("refusing to seed a production database")
+	}
+
+	dataset, err := seed.Run(ctx, seed.Repositories{
+		Users:    repository.NewUserRepository(db),
+		Orgs:     repository.NewOrganizationRepository(db),
+		Members:  repository.NewOrganizationMemberRepository(db),
+		Projects: repository.NewProjectRepository(db),
+		Tasks:    repository.NewTaskRepository(db),
+	}, opts)
+	if err != nil {
+		return err
+	}
+
+	orgs, users, projects, tasks := dataset.Counts()
+	log.Printf("Seeded %d organizations, %d users, %d projects and %d tasks", orgs, users, projects, tasks)
+	return nil
+}
@@ -0,0 +1,174 @@
+package apperror
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// Code identifies the kind of error. Codes are part of the API contract, clients
+// branch on them rather than on messages
+type Code string
+
+const (
+	CodeBadRequest   Code = "bad_request"
+	CodeValidation   Code = "validation_failed"
+	CodeUnauthorized Code = "unauthorized"
+	CodeForbidden    Code = "forbidden"
+	CodeNotFound     Code = "not_found"
+	CodeConflict     Code = "conflict"
+	CodeTimeout      Code = "timeout"
+	CodeInternal     Code = "internal_error"
+)
+
+var statuses = map[Code]int{
+	CodeBadRequest:   http.StatusBadRequest,
+	CodeValidation:   http.StatusBadRequest,
+	CodeUnauthorized: http.StatusUnauthorized,
+	CodeForbidden:    http.StatusForbidden,
+	CodeNotFound:     http.StatusNotFound,
+	CodeConflict:     http.StatusConflict,
+	CodeTimeout:      http.StatusGatewayTimeout,
+	CodeInternal:     http.StatusInternalServerError,
+}
+
+// Sentinels to match with errors.Is, any *Error with the same code matches
+var (
+	ErrBadRequest   = &Error{Code: CodeBadRequest}
+	ErrValidation   = &Error{Code: CodeValidation}
+	ErrUnauthorized = &Error{Code: CodeUnauthorized}
+	ErrForbidden    = &Error{Code: CodeForbidden}
+	ErrNotFound     = &Error{Code: CodeNotFound}
+	ErrConflict     = &Error{Code: CodeConflict}
+	ErrTimeout      = &Error{Code: CodeTimeout}
+	ErrInternal     = &Error{Code: CodeInternal}
+)
+
+// Error is an error a client can be told about. Message is written to the
+// response as is, so it must not contain internal details; those belong in the
+// cause, which is only logged
+type Error struct {
+	Code    Code
+	Message string
+	// Fields lists the invalid fields of a validation error
+	Fields []FieldError
+	status int
+	cause  error
+}
+
+// FieldError explains why one field of a request was rejected. Field is the
+// dotted JSON path, e.g. settings.timezone
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	msg := e.Message
+	if len(e.Fields) > 0 {
+		fields := make([]string, 0, len(e.Fields))
+		for _, f := range e.Fields {
+			fields = append(fields, f.Field+" "+f.Message)
+		}
+		msg += " (" + strings.Join(fields, "; ") + ")"
+	}
+	if e.cause != nil {
+		return msg + ": " + e.cause.Error()
+	}
+	return msg
+}
+
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// Status returns the HTTP status the error is answered with
+func (e *Error) Status() int {
+	if e.status != 0 {
+		return e.status
+	}
+	if status, ok := statuses[e.Code]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// WithFields attaches the invalid fields to a validation error
+func (e *Error) WithFields(fields []FieldError) *Error {
+	e.Fields = fields
+	return e
+}
+
+// Wrap records the underlying error that caused e
+func (e *Error) Wrap(cause error) *Error {
+	e.cause = cause
+	return e
+}
+
+func New(code Code, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+// FromStatus builds an error for a status raised by the framework rather than
+// the application, e.g. 405 gets the code method_not_allowed
+func FromStatus(status int, message string) *Error {
+	code := Code(strings.ReplaceAll(strings.ToLower(http.StatusText(status)), " ", "_"))
+	for c, s := range statuses {
+		if s == status && c != CodeValidation {
+			code = c
+		}
+	}
+	if code == "" {
+		code = CodeInternal
+	}
+	return &Error{Code: code, Message: message, status: status}
+}
+
+func BadRequest(message string) *Error {
+	return New(CodeBadRequest, message)
+}
+
+func Validation(message string) *Error {
+	return New(CodeValidation, message)
+}
+
+func Unauthorized(message string) *Error {
+	return New(CodeUnauthorized, message)
+}
+
+func Forbidden(message string) *Error {
+	return New(CodeForbidden, message)
+}
+
+func NotFound(message string) *Error {
+	return New(CodeNotFound, message)
+}
+
+func Conflict(message string) *Error {
+	return New(CodeConflict, message)
+}
+
+func Timeout(message string) *Error {
+	return New(CodeTimeout, message)
+}
+
+// Internal reports a failure the client can't do anything about. message says
+// what failed in general terms, cause is logged but never sent
+func Internal(message string, cause error) *Error {
+	return New(CodeInternal, message).Wrap(cause)
+}
+
+// As returns the *Error in err's chain, if any
+func As(err error) (*Error, bool) {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr, true
+	}
+	return nil, false
+}
@@ -0,0 +1,64 @@
+// Package audit carries who is making a change, and from where, on the
+// context, so the audit service can attribute the events it records
+package audit
+
+import (
+	"context"
+
+	"github.com/atavada/project-management-saas/internal/models"
+)
+
+// Actor is who a change is attributed to
+type Actor struct {
+	Type models.AuditActorType
+	// ClerkUserID identifies a user actor
+	ClerkUserID string
+	// Name names a service account, or the system a webhook came from
+	Name string
+}
+
+// System is the actor of changes made outside of any request or command
+var System = ServiceAccount("system")
+
+func User(clerkUserID string) Actor {
+	return Actor{Type: models.AuditActorUser, ClerkUserID: clerkUserID}
+}
+
+func ServiceAccount(name string) Actor {
+	return Actor{Type: models.AuditActorServiceAccount, Name: name}
+}
+
+func Webhook(source string) Actor {
+	return Actor{Type: models.AuditActorWebhook, Name: source}
+}
+
+type ctxKey int
+
+const (
+	actorKey ctxKey = iota
+	clientIPKey
+)
+
+// WithActor attributes the changes made with ctx to actor
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorKey, actor)
+}
+
+// ActorFrom returns the actor attached to ctx, or System
+func ActorFrom(ctx context.Context) Actor {
+	if actor, ok := ctx.Value(actorKey).(Actor); ok {
+		return actor
+	}
+	return System
+}
+
+// WithClientIP attaches the address the request came from to ctx
+func WithClientIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, clientIPKey, ip)
+}
+
+// ClientIP returns the address the request came from, or ""
+func ClientIP(ctx context.Context) string {
+	ip, _ := ctx.Value(clientIPKey).(string)
+	return ip
+}
@@ -1,47 +1,358 @@
 package config
 
 import (
+	"errors"
+	"fmt"
+	"log/slog"
 	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/BurntSushi/toml"
+	"github.com/atavada/project-management-saas/internal/database"
+	"github.com/atavada/project-management-saas/internal/tracing"
 	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
 )
 
+type Environment string
+
+const (
+	Development Environment = "development"
+	Test        Environment = "test"
+	Staging     Environment = "staging"
+	Production  Environment = "production"
+)
+
+func (e Environment) IsValid() bool {
+	switch e {
+	case Development, Test, Staging, Production:
+		return true
+	}
+	return false
+}
+
+// Config is read from defaults, then an optional YAML or TOML file named by
+// CONFIG_FILE, then environment variables, each overriding the previous one.
+// Fields tagged secret are redacted when printed
 type Config struct {
-	DatabaseURL 				string
-	Port								string
-	ClerkSecretKey 			string
-	ClerkPublishableKey string
-	ClerkWebhookSecret  string
-	InngestEventKey     string
-	InngestBaseURL      string
-	AllowedOrigins      string
-	Environment         string
+	DatabaseURL         string        `yaml:"database_url" toml:"database_url" env:"DATABASE_URL" secret:"true"`
+	DatabaseReplicaURL  string        `yaml:"database_replica_url" toml:"database_replica_url" env:"DATABASE_REPLICA_URL" secret:"true"`
+	DBMaxConns          int           `yaml:"db_max_conns" toml:"db_max_conns" env:"DB_MAX_CONNS"`
+	DBMinConns          int           `yaml:"db_min_conns" toml:"db_min_conns" env:"DB_MIN_CONNS"`
+	DBMaxConnLifetime   time.Duration `yaml:"db_max_conn_lifetime" toml:"db_max_conn_lifetime" env:"DB_MAX_CONN_LIFETIME"`
+	DBMaxConnIdleTime   time.Duration `yaml:"db_max_conn_idle_time" toml:"db_max_conn_idle_time" env:"DB_MAX_CONN_IDLE_TIME"`
+	DBHealthCheckPeriod time.Duration `yaml:"db_health_check_period" toml:"db_health_check_period" env:"DB_HEALTH_CHECK_PERIOD"`
+	DBStatementTimeout  time.Duration `yaml:"db_statement_timeout" toml:"db_statement_timeout" env:"DB_STATEMENT_TIMEOUT"`
+	DBApplicationName   string        `yaml:"db_application_name" toml:"db_application_name" env:"DB_APPLICATION_NAME"`
+	Port                int           `yaml:"port" toml:"port" env:"PORT"`
+	ClerkSecretKey      string        `yaml:"clerk_secret_key" toml:"clerk_secret_key" env:"CLERK_SECRET_KEY" secret:"true"`
+	ClerkPublishableKey string        `yaml:"clerk_publishable_key" toml:"clerk_publishable_key" env:"CLERK_PUBLISHABLE_KEY"`
+	ClerkWebhookSecret  string        `yaml:"clerk_webhook_secret" toml:"clerk_webhook_secret" env:"CLERK_WEBHOOK_SECRET" secret:"true"`
+	InngestEventKey     string        `yaml:"inngest_event_key" toml:"inngest_event_key" env:"INNGEST_EVENT_KEY" secret:"true"`
+	InngestBaseURL      string        `yaml:"inngest_base_url" toml:"inngest_base_url" env:"INNGEST_BASE_URL"`
+	AllowedOrigins      []string      `yaml:"allowed_origins" toml:"allowed_origins" env:"ALLOWED_ORIGINS"`
+	AdminUserIDs        []string      `yaml:"admin_user_ids" toml:"admin_user_ids" env:"ADMIN_USER_IDS"`
+	Environment         Environment   `yaml:"env" toml:"env" env:"ENV"`
+	AutoMigrate         bool          `yaml:"auto_migrate" toml:"auto_migrate" env:"AUTO_MIGRATE"`
+	ReadTimeout         time.Duration `yaml:"read_timeout" toml:"read_timeout" env:"READ_TIMEOUT"`
+	WriteTimeout        time.Duration `yaml:"write_timeout" toml:"write_timeout" env:"WRITE_TIMEOUT"`
+	IdleTimeout         time.Duration `yaml:"idle_timeout" toml:"idle_timeout" env:"IDLE_TIMEOUT"`
+	ShutdownTimeout     time.Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT"`
+	RequestTimeout      time.Duration `yaml:"request_timeout" toml:"request_timeout" env:"REQUEST_TIMEOUT"`
+	WebhookTimeout      time.Duration `yaml:"webhook_timeout" toml:"webhook_timeout" env:"WEBHOOK_TIMEOUT"`
+	TracingExporter     string        `yaml:"tracing_exporter" toml:"tracing_exporter" env:"TRACING_EXPORTER"`
+	TracingSampleRatio  float64       `yaml:"tracing_sample_ratio" toml:"tracing_sample_ratio" env:"TRACING_SAMPLE_RATIO"`
+	OTLPEndpoint        string        `yaml:"otlp_endpoint" toml:"otlp_endpoint" env:"OTLP_ENDPOINT"`
+	LogLevel            string        `yaml:"log_level" toml:"log_level" env:"LOG_LEVEL"`
+}
+
+// Defaults returns the settings used before the config file and environment
+// are applied
+func Defaults() *Config {
+	return &Config{
+		DBMaxConns:          25,
+		DBMinConns:          5,
+		DBMaxConnLifetime:   time.Hour,
+		DBMaxConnIdleTime:   5 * time.Minute,
+		DBHealthCheckPeriod: time.Minute,
+		DBStatementTimeout:  30 * time.Second,
+		DBApplicationName:   "align-api",
+		Port:                8080,
+		InngestBaseURL:      "https://inn.gs",
+		AllowedOrigins:      []string{"http://localhost:3000"},
+		Environment:         Development,
+		ReadTimeout:         15 * time.Second,
+		WriteTimeout:        30 * time.Second,
+		IdleTimeout:         2 * time.Minute,
+		ShutdownTimeout:     30 * time.Second,
+		RequestTimeout:      10 * time.Second,
+		WebhookTimeout:      30 * time.Second,
+		TracingExporter:     tracing.ExporterNone,
+		TracingSampleRatio:  1,
+		LogLevel:            "info",
+	}
 }
 
 func Load() (*Config, error) {
 	// Load .env file
-	if os.Getenv("ENV") != "production" {
+	if os.Getenv("ENV") != string(Production) {
 		godotenv.Load()
 	}
 
-	config := &Config{
-		DatabaseURL:         getEnv("DATABASE_URL", ""),
-		Port:                getEnv("PORT", "8080"),
-		ClerkSecretKey:      getEnv("CLERK_SECRET_KEY", ""),
-		ClerkPublishableKey: getEnv("CLERK_PUBLISHABLE_KEY", ""),
-		ClerkWebhookSecret:  getEnv("CLERK_WEBHOOK_SECRET", ""),
-		InngestEventKey:     getEnv("INNGEST_EVENT_KEY", ""),
-		InngestBaseURL:      getEnv("INNGEST_BASE_URL", "https://inn.gs"),
-		AllowedOrigins:      getEnv("ALLOWED_ORIGINS", "http://localhost:3000"),
-		Environment:         getEnv("ENV", "development"),
+	config := Defaults()
+
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		if err := loadFile(path, config); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := applyEnv(config); err != nil {
+		return nil, err
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, err
 	}
 
 	return config, nil
 }
 
-func getEnv(key, fallback string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+// Validate checks the settings required by the current environment. Clerk keys
+// may be left empty in development and test, where the CLI and seed data work
+// without Clerk
+func (c *Config) Validate() error {
+	var errs []error
+
+	if !c.Environment.IsValid() {
+		errs = append(errs, fmt.Errorf("ENV must be one of development, test, staging or production, got %q", c.Environment))
+	}
+	if c.DatabaseURL == "" {
+		errs = append(errs, errors.New("DATABASE_URL is required"))
+	}
+	if c.DBMaxConns < 1 {
+		errs = append(errs, fmt.Errorf("DB_MAX_CONNS must be at least 1, got %d", c.DBMaxConns))
+	}
+	if c.DBMinConns < 0 || c.DBMinConns > c.DBMaxConns {
+		errs = append(errs, fmt.Errorf("DB_MIN_CONNS must be between 0 and DB_MAX_CONNS, got %d", c.DBMinConns))
+	}
+	if c.Port < 1 || c.Port > 65535 {
+		errs = append(errs, fmt.Errorf("PORT must be between 1 and 65535, got %d", c.Port))
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.LogLevel)); err != nil {
+		errs = append(errs, fmt.Errorf("LOG_LEVEL must be one of debug, info, warn or error, got %q", c.LogLevel))
+	}
+	switch c.TracingExporter {
+	case tracing.ExporterNone, tracing.ExporterStdout, tracing.ExporterOTLP:
+	default:
+		errs = append(errs, fmt.Errorf("TRACING_EXPORTER must be one of none, stdout or otlp, got %q", c.TracingExporter))
+	}
+	if c.TracingSampleRatio < 0 || c.TracingSampleRatio > 1 {
+		errs = append(errs, fmt.Errorf("TRACING_SAMPLE_RATIO must be between 0 and 1, got %g", c.TracingSampleRatio))
+	}
+
+	if c.Environment == Staging || c.Environment == Production {
+		if c.ClerkSecretKey == "" {
+			errs = append(errs, fmt.Errorf("CLERK_SECRET_KEY is required in %s", c.Environment))
+		}
+		if c.ClerkWebhookSecret == "" {
+			errs = append(errs, fmt.Errorf("CLERK_WEBHOOK_SECRET is required in %s", c.Environment))
+		}
+	}
+
+	if len(c.AllowedOrigins) == 0 {
+		errs = append(errs, errors.New("ALLOWED_ORIGINS must list at least one origin"))
+	}
+	for _, origin := range c.AllowedOrigins {
+		// Credentials are allowed, which browsers refuse together with a wildcard
+		if origin == "*" {
+			errs = append(errs, errors.New("ALLOWED_ORIGINS cannot contain * because credentials are allowed"))
+		}
+	}
+
+	for name, d := range map[string]time.Duration{
+		"READ_TIMEOUT":     c.ReadTimeout,
+		"WRITE_TIMEOUT":    c.WriteTimeout,
+		"IDLE_TIMEOUT":     c.IdleTimeout,
+		"SHUTDOWN_TIMEOUT": c.ShutdownTimeout,
+		"REQUEST_TIMEOUT":  c.RequestTimeout,
+		"WEBHOOK_TIMEOUT":  c.WebhookTimeout,
+
+		"DB_MAX_CONN_LIFETIME":   c.DBMaxConnLifetime,
+		"DB_MAX_CONN_IDLE_TIME":  c.DBMaxConnIdleTime,
+		"DB_HEALTH_CHECK_PERIOD": c.DBHealthCheckPeriod,
+		"DB_STATEMENT_TIMEOUT":   c.DBStatementTimeout,
+	} {
+		if d < 0 {
+			errs = append(errs, fmt.Errorf("%s cannot be negative", name))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid config: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// Database returns the settings for database.New
+func (c *Config) Database() database.Config {
+	return database.Config{
+		URL:               c.DatabaseURL,
+		ReplicaURL:        c.DatabaseReplicaURL,
+		MaxConns:          int32(c.DBMaxConns),
+		MinConns:          int32(c.DBMinConns),
+		MaxConnLifetime:   c.DBMaxConnLifetime,
+		MaxConnIdleTime:   c.DBMaxConnIdleTime,
+		HealthCheckPeriod: c.DBHealthCheckPeriod,
+		StatementTimeout:  c.DBStatementTimeout,
+		ApplicationName:   c.DBApplicationName,
+	}
+}
+
+// Tracing returns the settings for tracing.Setup
+func (c *Config) Tracing(version string) tracing.Config {
+	return tracing.Config{
+		Exporter:       c.TracingExporter,
+		OTLPEndpoint:   c.OTLPEndpoint,
+		SampleRatio:    c.TracingSampleRatio,
+		ServiceName:    c.DBApplicationName,
+		ServiceVersion: version,
+	}
+}
+
+// SlogLevel returns the minimum level of log lines written, info when LogLevel
+// is not a valid level
+func (c *Config) SlogLevel() slog.Level {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.LogLevel)); err != nil {
+		return slog.LevelInfo
+	}
+	return level
+}
+
+// IsDevelopment reports whether the server runs locally
+func (c *Config) IsDevelopment() bool {
+	return c.Environment == Development
+}
+
+// Fields lists every setting by environment variable name, with secrets
+// redacted, in declaration order
+func (c *Config) Fields() [][2]string {
+	var fields [][2]string
+
+	v := reflect.ValueOf(c).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		value := v.Field(i)
+
+		var s string
+		switch {
+		case field.Tag.Get("secret") == "true" && value.String() == "":
+			s = "(not set)"
+		case field.Tag.Get("secret") == "true":
+			s = "********"
+		case value.Kind() == reflect.Slice:
+			s = strings.Join(value.Interface().([]string), ",")
+		default:
+			s = fmt.Sprint(value.Interface())
+		}
+
+		fields = append(fields, [2]string{field.Tag.Get("env"), s})
+	}
+
+	return fields
+}
+
+func loadFile(path string, config *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("error reading config file: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, config)
+	case ".toml":
+		_, err = toml.Decode(string(data), config)
+	default:
+		return fmt.Errorf("unsupported config file format: %s", path)
+	}
+	if err != nil {
+		return fmt.Errorf("error parsing config file %s: %w", path, err)
 	}
-	return fallback
-}
\ No newline at end of file
+
+	return nil
+}
+
+// applyEnv overrides fields with the environment variable named in their env tag
+func applyEnv(config *Config) error {
+	v := reflect.ValueOf(config).Elem()
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		key := field.Tag.Get("env")
+		raw := os.Getenv(key)
+		if key == "" || raw == "" {
+			continue
+		}
+
+		if err := setField(v.Field(i), raw); err != nil {
+			return fmt.Errorf("invalid %s: %w", key, err)
+		}
+	}
+
+	return nil
+}
+
+func setField(field reflect.Value, raw string) error {
+	switch field.Interface().(type) {
+	case time.Duration:
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+	case []string:
+		var values []string
+		for _, part := range strings.Split(raw, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				values = append(values, part)
+			}
+		}
+		field.Set(reflect.ValueOf(values))
+	default:
+		switch field.Kind() {
+		case reflect.String:
+			field.SetString(raw)
+		case reflect.Int, reflect.Int32, reflect.Int64:
+			n, err := strconv.ParseInt(raw, 10, 64)
+			if err != nil {
+				return err
+			}
+			field.SetInt(n)
+		case reflect.Bool:
+			b, err := strconv.ParseBool(raw)
+			if err != nil {
+				return err
+			}
+			field.SetBool(b)
+		case reflect.Float64:
+			f, err := strconv.ParseFloat(raw, 64)
+			if err != nil {
+				return err
+			}
+			field.SetFloat(f)
+		default:
+			return fmt.Errorf("unsupported config field type %s", field.Type())
+		}
+	}
+
+	return nil
+}
@@ -3,7 +3,8 @@ package database
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
+	"strconv"
 	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -11,9 +12,47 @@ import (
 
 type DB struct {
 	Pool *pgxpool.Pool
+	// Replica serves list and report queries, it is nil without a replica
+	Replica *pgxpool.Pool
 }
 
-func New(databaseURL string) (*DB, error) {
+// Config sizes the connection pools. Zero values keep the pgx defaults
+type Config struct {
+	URL               string
+	ReplicaURL        string
+	MaxConns          int32
+	MinConns          int32
+	MaxConnLifetime   time.Duration
+	MaxConnIdleTime   time.Duration
+	HealthCheckPeriod time.Duration
+	StatementTimeout  time.Duration
+	ApplicationName   string
+}
+
+func New(cfg Config) (*DB, error) {
+	pool, err := newPool(cfg, cfg.URL, "primary")
+	if err != nil {
+		return nil, err
+	}
+
+	db := &DB{Pool: pool}
+
+	if cfg.ReplicaURL != "" {
+		replica, err := newPool(cfg, cfg.ReplicaURL, "replica")
+		if err != nil {
+			pool.Close()
+			return nil, fmt.Errorf("error connecting to replica: %w", err)
+		}
+		db.Replica = replica
+		slog.Info("Database replica pool established")
+	}
+
+	slog.Info("Database connection pool established")
+
+	return db, nil
+}
+
+func newPool(cfg Config, databaseURL, name string) (*pgxpool.Pool, error) {
 	ctx := context.Background()
 
 	// Configure connection pool
@@ -23,11 +62,31 @@ func New(databaseURL string) (*DB, error) {
 	}
 
 	// Set pool config
-	config.MaxConns = 25
-	config.MinConns = 5
-	config.MaxConnIdleTime = 5 * time.Minute
-	config.HealthCheckPeriod = 1 * time.Minute
-	config.HealthCheckPeriod = 1 * time.Minute
+	if cfg.MaxConns > 0 {
+		config.MaxConns = cfg.MaxConns
+	}
+	if cfg.MinConns > 0 {
+		config.MinConns = cfg.MinConns
+	}
+	if cfg.MaxConnLifetime > 0 {
+		config.MaxConnLifetime = cfg.MaxConnLifetime
+	}
+	if cfg.MaxConnIdleTime > 0 {
+		config.MaxConnIdleTime = cfg.MaxConnIdleTime
+	}
+	if cfg.HealthCheckPeriod > 0 {
+		config.HealthCheckPeriod = cfg.HealthCheckPeriod
+	}
+
+	// Session settings sent when each connection is opened
+	if cfg.StatementTimeout > 0 {
+		config.ConnConfig.RuntimeParams["statement_timeout"] = strconv.FormatInt(cfg.StatementTimeout.Milliseconds(), 10)
+	}
+	if cfg.ApplicationName != "" {
+		config.ConnConfig.RuntimeParams["application_name"] = cfg.ApplicationName
+	}
+
+	config.ConnConfig.Tracer = queryTracer{pool: name}
 
 	// Create connection pool
 	pool, err := pgxpool.NewWithConfig(ctx, config)
@@ -37,14 +96,25 @@ func New(databaseURL string) (*DB, error) {
 
 	// Test connection
 	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
 		return nil, fmt.Errorf("error connecting to database: %w", err)
 	}
 
-	log.Println("Database connection pool established")
+	return pool, nil
+}
 
-	return &DB{Pool: pool}, nil
+// Reader returns the pool for read-only list and report queries. Replicas lag
+// behind the primary, so reads that must see a write just made use Pool
+func (db *DB) Reader() *pgxpool.Pool {
+	if db.Replica != nil {
+		return db.Replica
+	}
+	return db.Pool
 }
 
 func (db *DB) Close() {
+	if db.Replica != nil {
+		db.Replica.Close()
+	}
 	db.Pool.Close()
-}
\ No newline at end of file
+}
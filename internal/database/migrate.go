@@ -0,0 +1,408 @@
+package database
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationLockID is the Postgres advisory lock key held while migrating, so
+// replicas starting at the same time don't apply migrations concurrently
+const migrationLockID int64 = 7314652201
+
+// Migration is a pair of up/down SQL scripts sharing a version number
+type Migration struct {
+	Version uint
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus describes where the database stands relative to the
+// embedded migrations
+type MigrationStatus struct {
+	Version uint
+	Dirty   bool
+	Latest  uint
+	Pending []Migration
+}
+
+// Current reports whether every embedded migration has been applied
+func (s *MigrationStatus) Current() bool {
+	return !s.Dirty && len(s.Pending) == 0
+}
+
+// Migrator applies the migrations embedded in the binary. The schema_migrations
+// table uses the same layout as golang-migrate, so databases migrated with the
+// external tool keep their version
+type Migrator struct {
+	pool       *pgxpool.Pool
+	migrations []Migration
+}
+
+func NewMigrator(db *DB) (*Migrator, error) {
+	migrations, err := LoadMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{pool: db.Pool, migrations: migrations}, nil
+}
+
+// LoadMigrations parses the embedded NNNNNN_name.{up,down}.sql files, sorted by version
+func LoadMigrations() ([]Migration, error) {
+	entries, err := fs.ReadDir(migrationFiles, "migrations")
+	if err != nil {
+		return nil, fmt.Errorf("error reading migrations: %w", err)
+	}
+
+	byVersion := make(map[uint]*Migration)
+	for _, entry := range entries {
+		file := entry.Name()
+
+		versionPart, rest, ok := strings.Cut(file, "_")
+		if !ok {
+			return nil, fmt.Errorf("invalid migration file name: %s", file)
+		}
+		version, err := strconv.ParseUint(versionPart, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %s: %w", file, err)
+		}
+
+		var name, direction string
+		switch {
+		case strings.HasSuffix(rest, ".up.sql"):
+			name, direction = strings.TrimSuffix(rest, ".up.sql"), "up"
+		case strings.HasSuffix(rest, ".down.sql"):
+			name, direction = strings.TrimSuffix(rest, ".down.sql"), "down"
+		default:
+			return nil, fmt.Errorf("invalid migration file name: %s", file)
+		}
+
+		content, err := migrationFiles.ReadFile(path.Join("migrations", file))
+		if err != nil {
+			return nil, fmt.Errorf("error reading %s: %w", file, err)
+		}
+
+		m, ok := byVersion[uint(version)]
+		if !ok {
+			m = &Migration{Version: uint(version), Name: name}
+			byVersion[uint(version)] = m
+		}
+		if direction == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if strings.TrimSpace(m.Up) == "" || strings.TrimSpace(m.Down) == "" {
+			return nil, fmt.Errorf("migration %06d_%s must have non-empty up and down scripts", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// Status returns the applied version and the migrations still pending
+func (m *Migrator) Status(ctx context.Context) (*MigrationStatus, error) {
+	conn, err := m.pool.Acquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error acquiring connection: %w", err)
+	}
+	defer conn.Release()
+
+	// Status backs the readiness probe, so it only reads. A database that was
+	// never migrated has no schema_migrations table yet
+	var exists bool
+	if err := conn.QueryRow(ctx, "SELECT to_regclass('schema_migrations') IS NOT NULL").Scan(&exists); err != nil {
+		return nil, fmt.Errorf("error checking schema_migrations: %w", err)
+	}
+
+	var version uint
+	var dirty bool
+	if exists {
+		version, dirty, err = readVersion(ctx, conn.Conn())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	status := &MigrationStatus{Version: version, Dirty: dirty}
+	for _, migration := range m.migrations {
+		status.Latest = migration.Version
+		if migration.Version > version {
+			status.Pending = append(status.Pending, migration)
+		}
+	}
+
+	return status, nil
+}
+
+// Up applies up to steps pending migrations, all of them when steps <= 0
+func (m *Migrator) Up(ctx context.Context, steps int) error {
+	return m.withLock(ctx, func(conn *pgx.Conn, version uint) error {
+		applied := 0
+		for _, migration := range m.migrations {
+			if migration.Version <= version {
+				continue
+			}
+			if steps > 0 && applied == steps {
+				break
+			}
+			if err := m.apply(ctx, conn, migration.Up, migration.Version, migration); err != nil {
+				return err
+			}
+			applied++
+		}
+
+		if applied == 0 {
+			slog.InfoContext(ctx, "No pending migrations")
+		}
+		return nil
+	})
+}
+
+// Down reverts the last steps applied migrations, all of them when steps <= 0
+func (m *Migrator) Down(ctx context.Context, steps int) error {
+	return m.withLock(ctx, func(conn *pgx.Conn, version uint) error {
+		reverted := 0
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			migration := m.migrations[i]
+			if migration.Version > version {
+				continue
+			}
+			if steps > 0 && reverted == steps {
+				break
+			}
+			if err := m.apply(ctx, conn, migration.Down, m.previousVersion(i), migration); err != nil {
+				return err
+			}
+			reverted++
+		}
+
+		if reverted == 0 {
+			slog.InfoContext(ctx, "No migrations to revert")
+		}
+		return nil
+	})
+}
+
+// Goto migrates up or down until the database is at the given version. Version
+// 0 reverts every migration
+func (m *Migrator) Goto(ctx context.Context, target uint) error {
+	if target != 0 && m.indexOf(target) < 0 {
+		return fmt.Errorf("unknown migration version: %d", target)
+	}
+
+	return m.withLock(ctx, func(conn *pgx.Conn, version uint) error {
+		if target >= version {
+			for _, migration := range m.migrations {
+				if migration.Version <= version || migration.Version > target {
+					continue
+				}
+				if err := m.apply(ctx, conn, migration.Up, migration.Version, migration); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			migration := m.migrations[i]
+			if migration.Version > version || migration.Version <= target {
+				continue
+			}
+			if err := m.apply(ctx, conn, migration.Down, m.previousVersion(i), migration); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Force records version as applied and clears the dirty flag without running
+// any SQL. It is meant for recovering from a migration that failed halfway
+func (m *Migrator) Force(ctx context.Context, version uint) error {
+	conn, err := acquireUnbounded(ctx, m.pool)
+	if err != nil {
+		return err
+	}
+	defer releaseUnbounded(conn)
+
+	if err := lock(ctx, conn.Conn()); err != nil {
+		return err
+	}
+	defer unlock(conn.Conn())
+
+	if err := ensureVersionTable(ctx, conn.Conn()); err != nil {
+		return err
+	}
+
+	return pgx.BeginFunc(ctx, conn.Conn(), func(tx pgx.Tx) error {
+		return writeVersion(ctx, tx, version)
+	})
+}
+
+// withLock runs fn on a single connection holding the migration advisory lock
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *pgx.Conn, version uint) error) error {
+	conn, err := acquireUnbounded(ctx, m.pool)
+	if err != nil {
+		return err
+	}
+	defer releaseUnbounded(conn)
+
+	if err := lock(ctx, conn.Conn()); err != nil {
+		return err
+	}
+	defer unlock(conn.Conn())
+
+	if err := ensureVersionTable(ctx, conn.Conn()); err != nil {
+		return err
+	}
+
+	version, dirty, err := readVersion(ctx, conn.Conn())
+	if err != nil {
+		return err
+	}
+	if dirty {
+		return fmt.Errorf("database is dirty at version %d, fix it manually and run force", version)
+	}
+
+	return fn(conn.Conn(), version)
+}
+
+// apply runs one script and records the resulting version in the same transaction
+func (m *Migrator) apply(ctx context.Context, conn *pgx.Conn, script string, newVersion uint, migration Migration) error {
+	err := pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, script); err != nil {
+			return err
+		}
+		return writeVersion(ctx, tx, newVersion)
+	})
+	if err != nil {
+		return fmt.Errorf("error applying migration %06d_%s: %w", migration.Version, migration.Name, err)
+	}
+
+	slog.InfoContext(ctx, "Migrated", "migration", fmt.Sprintf("%06d_%s", migration.Version, migration.Name), "version", newVersion)
+	return nil
+}
+
+func (m *Migrator) indexOf(version uint) int {
+	for i, migration := range m.migrations {
+		if migration.Version == version {
+			return i
+		}
+	}
+	return -1
+}
+
+func (m *Migrator) previousVersion(i int) uint {
+	if i == 0 {
+		return 0
+	}
+	return m.migrations[i-1].Version
+}
+
+// acquireUnbounded takes a pool connection without the API's statement_timeout.
+// Migrations may rewrite large tables and the advisory lock waits for other
+// replicas to finish theirs, neither should be cut off after a few seconds
+func acquireUnbounded(ctx context.Context, pool *pgxpool.Pool) (*pgxpool.Conn, error) {
+	conn, err := pool.Acquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error acquiring connection: %w", err)
+	}
+
+	if _, err := conn.Exec(ctx, "SET statement_timeout = 0"); err != nil {
+		conn.Release()
+		return nil, fmt.Errorf("error disabling statement timeout: %w", err)
+	}
+	return conn, nil
+}
+
+// releaseUnbounded restores the pool's statement_timeout before returning the
+// connection, closing it instead when that fails
+func releaseUnbounded(conn *pgxpool.Conn) {
+	if _, err := conn.Exec(context.Background(), "RESET statement_timeout"); err != nil {
+		slog.Error("Error restoring statement timeout", "error", err)
+		conn.Conn().Close(context.Background())
+	}
+	conn.Release()
+}
+
+func lock(ctx context.Context, conn *pgx.Conn) error {
+	if _, err := conn.Exec(ctx, "SELECT pg_advisory_lock($1)", migrationLockID); err != nil {
+		return fmt.Errorf("error acquiring migration lock: %w", err)
+	}
+	return nil
+}
+
+func unlock(conn *pgx.Conn) {
+	// Use a fresh context so the lock is released even if ctx was cancelled
+	if _, err := conn.Exec(context.Background(), "SELECT pg_advisory_unlock($1)", migrationLockID); err != nil {
+		slog.Error("Error releasing migration lock", "error", err)
+	}
+}
+
+func ensureVersionTable(ctx context.Context, conn *pgx.Conn) error {
+	query := `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version BIGINT NOT NULL PRIMARY KEY,
+			dirty BOOLEAN NOT NULL
+		)
+	`
+
+	if _, err := conn.Exec(ctx, query); err != nil {
+		return fmt.Errorf("error creating schema_migrations: %w", err)
+	}
+	return nil
+}
+
+func readVersion(ctx context.Context, conn *pgx.Conn) (uint, bool, error) {
+	var version int64
+	var dirty bool
+	err := conn.QueryRow(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("error reading schema version: %w", err)
+	}
+	if version < 0 {
+		return 0, dirty, nil
+	}
+
+	return uint(version), dirty, nil
+}
+
+func writeVersion(ctx context.Context, tx pgx.Tx, version uint) error {
+	if _, err := tx.Exec(ctx, "DELETE FROM schema_migrations"); err != nil {
+		return fmt.Errorf("error writing schema version: %w", err)
+	}
+	if version == 0 {
+		return nil
+	}
+	if _, err := tx.Exec(ctx, "INSERT INTO schema_migrations (version, dirty) VALUES ($1, FALSE)", int64(version)); err != nil {
+		return fmt.Errorf("error writing schema version: %w", err)
+	}
+	return nil
+}
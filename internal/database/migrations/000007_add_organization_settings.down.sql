@@ -0,0 +1 @@
+ALTER TABLE organizations DROP COLUMN IF EXISTS settings;
@@ -0,0 +1,2 @@
+ALTER TABLE organizations
+    ADD COLUMN settings JSONB NOT NULL DEFAULT '{"default_task_priority": "medium", "working_days": ["mon", "tue", "wed", "thu", "fri"], "timezone": "UTC"}'::jsonb;
@@ -0,0 +1,2 @@
+DROP TRIGGER IF EXISTS update_user_preferences_updated_at ON user_preferences;
+DROP TABLE IF EXISTS user_preferences;
@@ -0,0 +1 @@
+CREATE INDEX idx_tasks_created_at ON tasks(created_at, id);
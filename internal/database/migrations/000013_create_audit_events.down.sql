@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS audit_events;
+DROP TYPE IF EXISTS audit_action;
+DROP TYPE IF EXISTS audit_actor_type;
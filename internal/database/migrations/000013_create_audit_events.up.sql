@@ -0,0 +1,23 @@
+CREATE TYPE audit_actor_type AS ENUM ('user', 'service_account', 'webhook');
+CREATE TYPE audit_action AS ENUM ('created', 'updated', 'deleted');
+
+-- Rows are only ever inserted. Deleting an organization or user keeps its
+-- events, with the reference cleared
+CREATE TABLE audit_events (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id UUID REFERENCES organizations(id) ON DELETE SET NULL,
+    actor_type audit_actor_type NOT NULL,
+    actor_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    actor_name VARCHAR(255) NOT NULL DEFAULT '',
+    entity_type VARCHAR(50) NOT NULL,
+    entity_id UUID NOT NULL,
+    action audit_action NOT NULL,
+    changes JSONB NOT NULL DEFAULT '{}'::jsonb,
+    ip_address INET,
+    request_id VARCHAR(128),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_audit_events_org_created_at ON audit_events(organization_id, created_at, id);
+CREATE INDEX idx_audit_events_entity ON audit_events(entity_type, entity_id);
+CREATE INDEX idx_audit_events_actor_id ON audit_events(actor_id);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS task_activity;
+DROP TYPE IF EXISTS task_activity_type;
@@ -0,0 +1,24 @@
+CREATE TYPE task_activity_type AS ENUM (
+    'status_changed',
+    'reassigned',
+    'priority_changed',
+    'due_date_changed',
+    'commented'
+);
+
+-- Written by the task service alongside each change. from_value and to_value
+-- hold the old and new status, priority, assignee ID or due date as text, a
+-- comment's text is kept in body
+CREATE TABLE task_activity (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    task_id UUID NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    actor_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    type task_activity_type NOT NULL,
+    from_value TEXT,
+    to_value TEXT,
+    body TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_task_activity_task_created_at ON task_activity(task_id, created_at, id);
+CREATE INDEX idx_task_activity_actor_id ON task_activity(actor_id);
@@ -0,0 +1,9 @@
+-- Keyset pages of tasks seek on (sort key, id) and order by the same pair.
+-- These serve both, and cover the single column indexes they replace
+CREATE INDEX idx_tasks_status_id ON tasks(status, id);
+CREATE INDEX idx_tasks_priority_id ON tasks(priority, id);
+CREATE INDEX idx_tasks_due_date_id ON tasks(due_date, id);
+
+DROP INDEX IF EXISTS idx_tasks_status;
+DROP INDEX IF EXISTS idx_tasks_priority;
+DROP INDEX IF EXISTS idx_tasks_due_date;
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"github.com/atavada/project-management-saas/internal/apperror"
+	"github.com/atavada/project-management-saas/internal/services"
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+)
+
+// AdminHandler serves the platform admin routes
+type AdminHandler struct {
+	erasureService *services.ErasureService
+}
+
+func NewAdminHandler(erasureService *services.ErasureService) *AdminHandler {
+	return &AdminHandler{
+		erasureService: erasureService,
+	}
+}
+
+// EraseUser deletes the Clerk account of the user in :id, then anonymizes them
+// and removes their preferences, exports and memberships, answering an erasure
+// request made outside of Clerk
+func (h *AdminHandler) EraseUser(c fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return apperror.BadRequest("Invalid user ID")
+	}
+
+	user, err := h.erasureService.EraseUser(c.Context(), userID)
+	if err != nil {
+		return wrapError("Failed to erase user", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"data": user,
+	})
+}
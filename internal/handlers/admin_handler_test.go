@@ -0,0 +1,132 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/atavada/project-management-saas/internal/apperror"
+	"github.com/atavada/project-management-saas/internal/listing"
+	"github.com/atavada/project-management-saas/internal/models"
+	"github.com/atavada/project-management-saas/internal/repository"
+	"github.com/atavada/project-management-saas/internal/testenv"
+)
+
+func TestEraseUserIsLimitedToAdmins(t *testing.T) {
+	env := testenv.NewMemory(t)
+
+	syncUser(t, env, testenv.AdminUserID, "admin@example.com")
+	syncUser(t, env, "user_owner", "owner@example.com")
+	user := syncUser(t, env, "user_member", "member@example.com")
+	org := syncOrganization(t, env, "org_1", "acme", "user_owner")
+	syncMembership(t, env, "org_1", "user_member", "org:member")
+
+	path := "/api/v1/admin/users/" + user.ID.String()
+
+	expectStatus(t, env.Do(t, http.MethodDelete, path, "user_owner", nil), http.StatusForbidden)
+	expectStatus(t, env.Do(t, http.MethodDelete, path, testenv.AdminUserID, nil), http.StatusOK)
+
+	_, err := env.Store.Members().GetMember(context.Background(), org.ID, user.ID)
+	if !errors.Is(err, apperror.ErrNotFound) {
+		t.Errorf("membership was kept, lookup returned %v", err)
+	}
+
+	expectStatus(t, env.Do(t, http.MethodGet, "/api/v1/users/me", "user_member", nil), http.StatusUnauthorized)
+	expectStatus(t, env.Do(t, http.MethodDelete, "/api/v1/admin/users/not-a-uuid", testenv.AdminUserID, nil), http.StatusBadRequest)
+}
+
+func TestEraseUserDeletesClerkAccount(t *testing.T) {
+	env := testenv.NewMemory(t)
+	ctx := context.Background()
+
+	syncUser(t, env, testenv.AdminUserID, "admin@example.com")
+	user := syncUser(t, env, "user_member", "member@example.com")
+
+	expectStatus(t, env.Do(t, http.MethodDelete, "/api/v1/admin/users/"+user.ID.String(), testenv.AdminUserID, nil), http.StatusOK)
+
+	if deleted := env.Clerk.Deleted(); len(deleted) != 1 || deleted[0] != "user_member" {
+		t.Fatalf("deleted %v in Clerk, want user_member", deleted)
+	}
+
+	// Clerk reports the deletion after the user was anonymized
+	deliver(t, env, env.Webhooks.Event("user.deleted", testenv.DeletedUserData("user_member")))
+
+	erased, err := env.Store.Users().GetByID(ctx, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if erased.Email == "member@example.com" {
+		t.Errorf("user kept their email after erasure")
+	}
+	if _, err := env.Store.Users().GetByClerkID(ctx, "user_member"); !errors.Is(err, apperror.ErrNotFound) {
+		t.Errorf("lookup by the old Clerk ID returned %v, want not found", err)
+	}
+}
+
+func TestEraseUserLeavesNoIdentifiersInAuditLog(t *testing.T) {
+	env := testenv.NewMemory(t)
+	ctx := context.Background()
+
+	syncUser(t, env, testenv.AdminUserID, "admin@example.com")
+	syncUser(t, env, "user_owner", "owner@example.com")
+	deliver(t, env, env.Webhooks.Event("user.created", testenv.UserData("user_grace", "grace@hopper.example", "Grace", "Hopper")))
+	org := syncOrganization(t, env, "org_1", "acme", "user_owner")
+	syncMembership(t, env, "org_1", "user_grace", "org:member")
+
+	user, err := env.Store.Users().GetByClerkID(ctx, "user_grace")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Events the user made themselves, with their client IP
+	resp := env.Do(t, http.MethodPatch, "/api/v1/users/me", "user_grace", map[string]any{"display_name": "Amazing Grace"})
+	expectStatus(t, resp, http.StatusOK)
+
+	expectStatus(t, env.Do(t, http.MethodDelete, "/api/v1/admin/users/"+user.ID.String(), testenv.AdminUserID, nil), http.StatusOK)
+
+	events, err := env.Store.AuditEvents().ListForUser(ctx, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	params, err := listing.Parse(map[string]string{"limit": "100"}, repository.AuditListSpec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	page, err := env.Store.AuditEvents().ListForOrganization(ctx, org.ID, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	events = append(events, page.Items...)
+	if len(events) == 0 {
+		t.Fatal("no events were recorded")
+	}
+
+	for _, event := range events {
+		if event.ActorID != nil && *event.ActorID == user.ID && event.IPAddress != nil {
+			t.Errorf("%s %s event kept the IP address %s", event.EntityType, event.Action, *event.IPAddress)
+		}
+
+		data, err := json.Marshal(event)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, identifier := range []string{"user_grace", "grace@hopper.example", "Grace", "Hopper"} {
+			if strings.Contains(string(data), identifier) {
+				t.Errorf("%s %s event kept %q: %s", event.EntityType, event.Action, identifier, data)
+			}
+		}
+	}
+
+	var deleted int
+	for _, event := range page.Items {
+		if event.EntityType == models.AuditEntityOrganizationMember && event.Action == models.AuditDeleted {
+			deleted++
+		}
+	}
+	if deleted != 1 {
+		t.Errorf("got %d membership deletions in the audit log, want 1", deleted)
+	}
+}
@@ -0,0 +1,19 @@
+package handlers
+
+import (
+	"github.com/atavada/project-management-saas/internal/apperror"
+	"github.com/gofiber/fiber/v3"
+)
+
+// bindBody decodes the request body into out and validates it. Validation
+// errors keep their field list, anything else means the body couldn't be parsed
+func bindBody(c fiber.Ctx, out any) error {
+	err := c.Bind().Body(out)
+	if err == nil {
+		return nil
+	}
+	if _, ok := apperror.As(err); ok {
+		return err
+	}
+	return apperror.BadRequest("Invalid request body")
+}
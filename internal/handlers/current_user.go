@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/atavada/project-management-saas/internal/apperror"
+	"github.com/atavada/project-management-saas/internal/models"
+	"github.com/atavada/project-management-saas/internal/repository"
+	"github.com/gofiber/fiber/v3"
+)
+
+// currentUser loads the authenticated user. A Clerk user without a local row,
+// because the webhook creating it has not arrived yet or the user was erased,
+// is answered with 401 on every route
+func currentUser(c fiber.Ctx, userRepo repository.UserRepository) (*models.User, error) {
+	clerkUserID := c.Locals("clerkUserID").(string)
+
+	user, err := userRepo.GetByClerkID(c.Context(), clerkUserID)
+	if errors.Is(err, apperror.ErrNotFound) {
+		return nil, apperror.Unauthorized("User not found")
+	}
+	if err != nil {
+		return nil, apperror.Internal("Failed to fetch user", err)
+	}
+
+	return user, nil
+}
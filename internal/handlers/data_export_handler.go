@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/atavada/project-management-saas/internal/apperror"
+	"github.com/atavada/project-management-saas/internal/models"
+	"github.com/atavada/project-management-saas/internal/repository"
+	"github.com/atavada/project-management-saas/internal/services"
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+)
+
+type DataExportHandler struct {
+	userRepo      repository.UserRepository
+	exportRepo    repository.DataExportRepository
+	exportService *services.ExportService
+}
+
+func NewDataExportHandler(
+	userRepo repository.UserRepository,
+	exportRepo repository.DataExportRepository,
+	exportService *services.ExportService,
+) *DataExportHandler {
+	return &DataExportHandler{
+		userRepo:      userRepo,
+		exportRepo:    exportRepo,
+		exportService: exportService,
+	}
+}
+
+// RequestExport starts building an archive of the authenticated user's data
+func (h *DataExportHandler) RequestExport(c fiber.Ctx) error {
+	ctx := c.Context()
+
+	user, err := currentUser(c, h.userRepo)
+	if err != nil {
+		return err
+	}
+
+	export, err := h.exportService.RequestExport(ctx, user.ID)
+	if err != nil {
+		return apperror.Internal("Failed to request export", err)
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"data": export,
+	})
+}
+
+// GetExport returns the status of one of the authenticated user's exports
+func (h *DataExportHandler) GetExport(c fiber.Ctx) error {
+	ctx := c.Context()
+
+	export, err := h.getOwnExport(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"data": export,
+	})
+}
+
+// DownloadExport serves the archive of a completed export
+func (h *DataExportHandler) DownloadExport(c fiber.Ctx) error {
+	ctx := c.Context()
+
+	export, err := h.getOwnExport(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	if export.Status != models.DataExportCompleted {
+		return apperror.Conflict("Export is not ready")
+	}
+
+	archive, err := h.exportRepo.GetArchive(ctx, export.ID)
+	if err != nil {
+		return wrapError("Failed to fetch export", err)
+	}
+
+	c.Set(fiber.HeaderContentType, "application/zip")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="data-export-%s.zip"`, export.ID))
+	return c.Send(archive)
+}
+
+// getOwnExport loads the export in :id and makes sure it belongs to the
+// authenticated user
+func (h *DataExportHandler) getOwnExport(ctx context.Context, c fiber.Ctx) (*models.DataExport, error) {
+	exportID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return nil, apperror.BadRequest("Invalid export ID")
+	}
+
+	user, err := currentUser(c, h.userRepo)
+	if err != nil {
+		return nil, err
+	}
+
+	// Someone else's export is reported as missing, not forbidden, so export
+	// IDs can't be probed
+	export, err := h.exportRepo.GetByID(ctx, exportID)
+	if err != nil {
+		return nil, wrapError("Failed to fetch export", err)
+	}
+	if export.UserID != user.ID {
+		return nil, apperror.NotFound("Export not found")
+	}
+
+	return export, nil
+}
@@ -0,0 +1,15 @@
+package handlers
+
+import (
+	"github.com/atavada/project-management-saas/internal/apperror"
+)
+
+// wrapError returns err as is when it is already an application error, like
+// the NotFound errors of repositories, and an internal error described by
+// message otherwise so the cause stays out of the response
+func wrapError(message string, err error) error {
+	if _, ok := apperror.As(err); ok {
+		return err
+	}
+	return apperror.Internal(message, err)
+}
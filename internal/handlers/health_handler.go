@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"github.com/atavada/project-management-saas/internal/database"
+	"github.com/gofiber/fiber/v3"
+)
+
+// readinessTimeout bounds each dependency check so a hung database fails the
+// probe instead of stalling it
+const readinessTimeout = 2 * time.Second
+
+type HealthHandler struct {
+	db       *database.DB
+	migrator *database.Migrator
+	version  string
+}
+
+func NewHealthHandler(db *database.DB, migrator *database.Migrator, version string) *HealthHandler {
+	return &HealthHandler{
+		db:       db,
+		migrator: migrator,
+		version:  version,
+	}
+}
+
+// Live reports that the process is up and serving requests. It checks no
+// dependencies so a database outage doesn't get the instance restarted
+func (h *HealthHandler) Live(c fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"status":  "ok",
+		"version": h.version,
+	})
+}
+
+// Ready reports whether the instance can serve traffic: the database answers
+// and the schema is at the latest embedded migration. Fails with 503 otherwise
+func (h *HealthHandler) Ready(c fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.Context(), readinessTimeout)
+	defer cancel()
+
+	ready := true
+	checks := fiber.Map{}
+
+	if err := h.db.Pool.Ping(ctx); err != nil {
+		ready = false
+		checks["database"] = fiber.Map{"status": "fail", "error": "Database unreachable"}
+	} else {
+		checks["database"] = fiber.Map{"status": "ok"}
+	}
+
+	if h.db.Replica != nil {
+		if err := h.db.Replica.Ping(ctx); err != nil {
+			ready = false
+			checks["replica"] = fiber.Map{"status": "fail", "error": "Replica unreachable"}
+		} else {
+			checks["replica"] = fiber.Map{"status": "ok"}
+		}
+	}
+
+	status, err := h.migrator.Status(ctx)
+	switch {
+	case err != nil:
+		ready = false
+		checks["migrations"] = fiber.Map{"status": "fail", "error": "Failed to read schema version"}
+	case !status.Current():
+		ready = false
+		checks["migrations"] = fiber.Map{
+			"status":  "fail",
+			"error":   "Schema is not up to date",
+			"version": status.Version,
+			"latest":  status.Latest,
+			"dirty":   status.Dirty,
+		}
+	default:
+		checks["migrations"] = fiber.Map{"status": "ok", "version": status.Version}
+	}
+
+	stat := h.db.Pool.Stat()
+	pool := fiber.Map{
+		"total_conns":    stat.TotalConns(),
+		"idle_conns":     stat.IdleConns(),
+		"acquired_conns": stat.AcquiredConns(),
+		"max_conns":      stat.MaxConns(),
+	}
+
+	code := fiber.StatusOK
+	result := "ok"
+	if !ready {
+		code = fiber.StatusServiceUnavailable
+		result = "unavailable"
+	}
+
+	return c.Status(code).JSON(fiber.Map{
+		"status":  result,
+		"version": h.version,
+		"checks":  checks,
+		"pool":    pool,
+	})
+}
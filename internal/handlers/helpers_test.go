@@ -0,0 +1,89 @@
+package handlers_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/atavada/project-management-saas/internal/models"
+	"github.com/atavada/project-management-saas/internal/testenv"
+	"github.com/google/uuid"
+)
+
+// syncUser delivers the user.created webhook for clerkUserID and returns the
+// local user
+func syncUser(t *testing.T, env *testenv.Env, clerkUserID, email string) *models.User {
+	t.Helper()
+
+	deliver(t, env, env.Webhooks.Event("user.created", testenv.UserData(clerkUserID, email, "Test", "User")))
+
+	user, err := env.Store.Users().GetByClerkID(context.Background(), clerkUserID)
+	if err != nil {
+		t.Fatalf("user %s was not synced: %v", clerkUserID, err)
+	}
+
+	return user
+}
+
+// syncOrganization delivers the organization.created webhook, which makes the
+// creator its owner
+func syncOrganization(t *testing.T, env *testenv.Env, clerkOrgID, slug, createdBy string) *models.Organization {
+	t.Helper()
+
+	deliver(t, env, env.Webhooks.Event("organization.created", testenv.OrganizationData(clerkOrgID, slug, slug, createdBy)))
+
+	org, err := env.Store.Organizations().GetByClerkID(context.Background(), clerkOrgID)
+	if err != nil {
+		t.Fatalf("organization %s was not synced: %v", clerkOrgID, err)
+	}
+
+	return org
+}
+
+// syncMembership delivers the organizationMembership.created webhook
+func syncMembership(t *testing.T, env *testenv.Env, clerkOrgID, clerkUserID, role string) {
+	t.Helper()
+
+	data := testenv.MembershipData("orgmem_"+clerkUserID, clerkOrgID, clerkUserID, role)
+	deliver(t, env, env.Webhooks.Event("organizationMembership.created", data))
+}
+
+// createTask adds a project with one task to the organization. Neither has an
+// API yet, so they are written to the store directly
+func createTask(t *testing.T, env *testenv.Env, orgID, createdBy uuid.UUID) *models.Task {
+	t.Helper()
+
+	ctx := context.Background()
+
+	project, err := env.Store.Projects().Create(ctx, &models.CreateProjectRequest{OrganizationID: orgID, Name: "Launch"})
+	if err != nil {
+		t.Fatalf("error creating project: %v", err)
+	}
+
+	task, err := env.Store.Tasks().Create(ctx, &models.CreateTaskRequest{
+		ProjectID: project.ID,
+		CreatedBy: createdBy,
+		Title:     "Write the announcement",
+	})
+	if err != nil {
+		t.Fatalf("error creating task: %v", err)
+	}
+
+	return task
+}
+
+func deliver(t *testing.T, env *testenv.Env, webhook *testenv.Webhook) {
+	t.Helper()
+
+	if resp := env.Deliver(t, webhook); resp.StatusCode != http.StatusOK {
+		t.Fatalf("webhook %s: got %d: %s", webhook.Type, resp.StatusCode, resp.Body)
+	}
+}
+
+func expectStatus(t *testing.T, resp *testenv.Response, status int) {
+	t.Helper()
+
+	if resp.StatusCode != status {
+		t.Fatalf("got %d, want %d: %s", resp.StatusCode, status, resp.Body)
+	}
+}
@@ -2,113 +2,304 @@ package handlers
 
 import (
 	"context"
+	"errors"
+	"net/url"
+	"strings"
 
+	"github.com/atavada/project-management-saas/internal/apperror"
+	"github.com/atavada/project-management-saas/internal/models"
 	"github.com/atavada/project-management-saas/internal/repository"
+	"github.com/atavada/project-management-saas/internal/services"
 	"github.com/gofiber/fiber/v3"
 	"github.com/google/uuid"
 )
 
 type OrganizationHandler struct {
-	userRepo *repository.UserRepository
-	orgRepo *repository.OrganizationRepository
-	memberRepo *repository.OrganizationMemberRepository
+	userRepo repository.UserRepository
+	orgRepo repository.OrganizationRepository
+	memberRepo repository.OrganizationMemberRepository
+	projectRepo repository.ProjectRepository
+	taskRepo repository.TaskRepository
+	auditService *services.AuditService
 }
 
 func NewOrganizationHandler(
-	userRepo *repository.UserRepository,
-	orgRepo *repository.OrganizationRepository,
-	memberRepo *repository.OrganizationMemberRepository,
+	userRepo repository.UserRepository,
+	orgRepo repository.OrganizationRepository,
+	memberRepo repository.OrganizationMemberRepository,
+	projectRepo repository.ProjectRepository,
+	taskRepo repository.TaskRepository,
+	auditService *services.AuditService,
 ) *OrganizationHandler {
 	return &OrganizationHandler{
 		userRepo: userRepo,
 		orgRepo: orgRepo,
 		memberRepo: memberRepo,
+		projectRepo: projectRepo,
+		taskRepo: taskRepo,
+		auditService: auditService,
 	}
 }
 
-// ListUserOrganizations returns all organizations the uis is a member of
+// ListUserOrganizations returns a page of the organizations the user is a member of
 func (h *OrganizationHandler) ListUserOrganizations(c fiber.Ctx) error {
-	ctx := context.Background()
-	clerkUserID := c.Locals("clerkUserID").(string)
+	ctx := c.Context()
+
+	params, err := listParams(c, repository.OrganizationListSpec)
+	if err != nil {
+		return err
+	}
 
 	// Get user from DB
-	user, err := h.userRepo.GetByClerkID(ctx, clerkUserID)
+	user, err := currentUser(c, h.userRepo)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to fetch user",
-		})
+		return err
 	}
-	if user == nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": "User not found",
-		})
+
+	// Get user's organizations
+	page, err := h.orgRepo.ListForUser(ctx, user.ID, params)
+	if err != nil {
+		return wrapError("Failed to fetch organizations", err)
 	}
 
-	// Get user's organization
-	organization, err := h.orgRepo.GetUserOrganizations(ctx, user.ID)
+	return c.JSON(paginated(page, params))
+}
+
+// GetOrganization returns details of a specific organization
+func (h *OrganizationHandler) GetOrganization(c fiber.Ctx) error {
+	org, member, previousSlug, err := h.authorizeMember(c)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to fetch organization",
-		})
+		return err
+	}
+
+	if previousSlug {
+		return redirectToCurrentSlug(c, org.Slug)
 	}
 
 	return c.JSON(fiber.Map{
-		"data": organization,
+		"data": fiber.Map{
+			"organization": org,
+			"role": member.Role,
+		},
 	})
 }
 
-// GetOrganization returns details of a specific organization
-func (h *OrganizationHandler) GetOrganization(c fiber.Ctx) error {
-	ctx := context.Background()
+// UpdateOrganization updates the description and settings of an organization,
+// restricted to owners and admins
+func (h *OrganizationHandler) UpdateOrganization(c fiber.Ctx) error {
+	ctx := c.Context()
 
-	orgID, err := uuid.Parse(c.Params("id"))
+	var req models.UpdateOrganizationRequest
+	if err := bindBody(c, &req); err != nil {
+		return err
+	}
+
+	org, member, previousSlug, err := h.authorizeMember(c)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid organization ID",
-		})
+		return err
 	}
 
-	clerkUserID := c.Locals("clerkUserID").(string)
+	// Only owners and admins can change organization settings
+	if member.Role != models.RoleOwner && member.Role != models.RoleAdmin {
+		return apperror.Forbidden("Access denied")
+	}
 
-	// Get user
-	user, err := h.userRepo.GetByClerkID(ctx, clerkUserID)
-	if err != nil || user == nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": "User not found",
-		})
+	if previousSlug {
+		return redirectToCurrentSlug(c, org.Slug)
 	}
 
-	// Check if user is member of the organization
-	member, err := h.memberRepo.GetMember(ctx, orgID, user.ID)
-	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to verify membership",
-		})
+	// Apply the partial update on top of the stored values
+	if req.Description != nil {
+		org.Description = *req.Description
 	}
-	if member == nil {
-		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-			"error": "Access denied",
-		})
+	if req.Settings != nil {
+		if req.Settings.DefaultTaskPriority != nil {
+			org.Settings.DefaultTaskPriority = *req.Settings.DefaultTaskPriority
+		}
+		if req.Settings.WorkingDays != nil {
+			org.Settings.WorkingDays = req.Settings.WorkingDays
+		}
+		if req.Settings.Timezone != nil {
+			org.Settings.Timezone = *req.Settings.Timezone
+		}
 	}
 
-	// Get organization
-	org, err := h.orgRepo.GetByID(ctx, orgID)
+	updated, err := h.orgRepo.Update(ctx, org)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to fetch organization",
-		})
-	}
-	if org == nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": "Organization not found",
-		})
+		return wrapError("Failed to update organization", err)
 	}
 
 	return c.JSON(fiber.Map{
 		"data": fiber.Map{
-			"organization": org,
+			"organization": updated,
 			"role": member.Role,
 		},
 	})
 }
 
+// ListMembers returns a page of the memberships of an organization
+func (h *OrganizationHandler) ListMembers(c fiber.Ctx) error {
+	params, err := listParams(c, repository.MemberListSpec)
+	if err != nil {
+		return err
+	}
+
+	org, _, previousSlug, err := h.authorizeMember(c)
+	if err != nil {
+		return err
+	}
+	if previousSlug {
+		return redirectToCurrentSlug(c, org.Slug)
+	}
+
+	page, err := h.memberRepo.ListMembers(c.Context(), org.ID, params)
+	if err != nil {
+		return wrapError("Failed to fetch members", err)
+	}
+
+	return c.JSON(paginated(page, params))
+}
+
+// ListProjects returns a page of the projects of an organization
+func (h *OrganizationHandler) ListProjects(c fiber.Ctx) error {
+	params, err := listParams(c, repository.ProjectListSpec)
+	if err != nil {
+		return err
+	}
+
+	org, _, previousSlug, err := h.authorizeMember(c)
+	if err != nil {
+		return err
+	}
+	if previousSlug {
+		return redirectToCurrentSlug(c, org.Slug)
+	}
+
+	page, err := h.projectRepo.ListForOrganization(c.Context(), org.ID, params)
+	if err != nil {
+		return wrapError("Failed to fetch projects", err)
+	}
+
+	return c.JSON(paginated(page, params))
+}
+
+// ListTasks returns a page of the tasks across all projects of an organization
+func (h *OrganizationHandler) ListTasks(c fiber.Ctx) error {
+	params, err := listParams(c, repository.TaskListSpec)
+	if err != nil {
+		return err
+	}
+
+	org, _, previousSlug, err := h.authorizeMember(c)
+	if err != nil {
+		return err
+	}
+	if previousSlug {
+		return redirectToCurrentSlug(c, org.Slug)
+	}
+
+	page, err := h.taskRepo.ListForOrganization(c.Context(), org.ID, params)
+	if err != nil {
+		return wrapError("Failed to fetch tasks", err)
+	}
+
+	return c.JSON(paginated(page, params))
+}
+
+// ListAuditLog returns a page of the audit log of an organization, newest
+// first. Only owners and admins can read it
+func (h *OrganizationHandler) ListAuditLog(c fiber.Ctx) error {
+	params, err := listParams(c, repository.AuditListSpec)
+	if err != nil {
+		return err
+	}
+
+	org, member, previousSlug, err := h.authorizeMember(c)
+	if err != nil {
+		return err
+	}
+	if member.Role != models.RoleOwner && member.Role != models.RoleAdmin {
+		return apperror.Forbidden("Access denied")
+	}
+	if previousSlug {
+		return redirectToCurrentSlug(c, org.Slug)
+	}
+
+	page, err := h.auditService.List(c.Context(), org.ID, params)
+	if err != nil {
+		return wrapError("Failed to fetch audit log", err)
+	}
+
+	return c.JSON(paginated(page, params))
+}
+
+// authorizeMember resolves the organization in the :id param and the
+// authenticated user's membership of it. Non-members get a Forbidden error
+func (h *OrganizationHandler) authorizeMember(c fiber.Ctx) (*models.Organization, *models.OrganizationMember, bool, error) {
+	ctx := c.Context()
+
+	org, previousSlug, err := h.resolveOrganization(ctx, c.Params("id"))
+	if err != nil {
+		return nil, nil, false, wrapError("Failed to fetch organization", err)
+	}
+
+	// Get user
+	user, err := currentUser(c, h.userRepo)
+	if err != nil {
+		return nil, nil, false, err
+	}
+
+	// Check if user is member of the organization
+	member, err := h.memberRepo.GetMember(ctx, org.ID, user.ID)
+	if errors.Is(err, apperror.ErrNotFound) {
+		return nil, nil, false, apperror.Forbidden("Access denied")
+	}
+	if err != nil {
+		return nil, nil, false, apperror.Internal("Failed to verify membership", err)
+	}
+
+	return org, member, previousSlug, nil
+}
+
+// resolveOrganization finds an organization by UUID or slug. previousSlug is
+// true when idOrSlug is a slug the organization was renamed away from
+func (h *OrganizationHandler) resolveOrganization(ctx context.Context, idOrSlug string) (*models.Organization, bool, error) {
+	if id, err := uuid.Parse(idOrSlug); err == nil {
+		org, err := h.orgRepo.GetByID(ctx, id)
+		return org, false, err
+	}
+
+	org, err := h.orgRepo.GetBySlug(ctx, idOrSlug)
+	if !errors.Is(err, apperror.ErrNotFound) {
+		return org, false, err
+	}
+
+	org, err = h.orgRepo.GetByPreviousSlug(ctx, idOrSlug)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return org, true, nil
+}
+
+// redirectToCurrentSlug sends the client to the same route under the
+// organization's current slug. The location is built from the matched route,
+// so only the :id segment is replaced even when the old slug equals another
+// segment like "api". 308 keeps the method and body intact
+func redirectToCurrentSlug(c fiber.Ctx, slug string) error {
+	route := strings.Split(c.Route().Path, "/")
+	path := strings.Split(c.Path(), "/")
+	for i, segment := range route {
+		if segment == ":id" && i < len(path) {
+			path[i] = url.PathEscape(slug)
+			break
+		}
+	}
+
+	location := strings.Join(path, "/")
+	if query := string(c.Request().URI().QueryString()); query != "" {
+		location += "?" + query
+	}
+
+	return c.Redirect().Status(fiber.StatusPermanentRedirect).To(location)
+}
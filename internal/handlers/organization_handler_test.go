@@ -0,0 +1,96 @@
+package handlers_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/atavada/project-management-saas/internal/models"
+	"github.com/atavada/project-management-saas/internal/testenv"
+)
+
+func TestOrganizationAccess(t *testing.T) {
+	env := testenv.NewMemory(t)
+
+	syncUser(t, env, "user_owner", "owner@example.com")
+	syncUser(t, env, "user_member", "member@example.com")
+	syncUser(t, env, "user_outsider", "outsider@example.com")
+	org := syncOrganization(t, env, "org_1", "acme", "user_owner")
+	syncMembership(t, env, "org_1", "user_member", "org:member")
+
+	tests := []struct {
+		name        string
+		path        string
+		clerkUserID string
+		status      int
+	}{
+		{"member by slug", "/api/v1/organizations/acme", "user_member", http.StatusOK},
+		{"member by ID", "/api/v1/organizations/" + org.ID.String(), "user_member", http.StatusOK},
+		{"member lists members", "/api/v1/organizations/acme/members", "user_member", http.StatusOK},
+		{"member lists tasks", "/api/v1/organizations/acme/tasks", "user_member", http.StatusOK},
+		{"member reads audit log", "/api/v1/organizations/acme/audit-log", "user_member", http.StatusForbidden},
+		{"owner reads audit log", "/api/v1/organizations/acme/audit-log", "user_owner", http.StatusOK},
+		{"non-member", "/api/v1/organizations/acme", "user_outsider", http.StatusForbidden},
+		{"non-member lists members", "/api/v1/organizations/acme/members", "user_outsider", http.StatusForbidden},
+		{"unknown organization", "/api/v1/organizations/globex", "user_member", http.StatusNotFound},
+		{"user not synced yet", "/api/v1/organizations/acme", "user_unknown", http.StatusUnauthorized},
+		{"no token", "/api/v1/organizations/acme", "", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expectStatus(t, env.Do(t, http.MethodGet, tt.path, tt.clerkUserID, nil), tt.status)
+		})
+	}
+}
+
+func TestListUserOrganizationsOnlyListsMemberships(t *testing.T) {
+	env := testenv.NewMemory(t)
+
+	syncUser(t, env, "user_1", "ada@example.com")
+	syncUser(t, env, "user_2", "grace@example.com")
+	syncOrganization(t, env, "org_1", "acme", "user_1")
+	syncOrganization(t, env, "org_2", "globex", "user_2")
+
+	resp := env.Do(t, http.MethodGet, "/api/v1/organizations", "user_1", nil)
+	expectStatus(t, resp, http.StatusOK)
+
+	var body struct {
+		Data []models.OrganizationWithRole `json:"data"`
+	}
+	resp.Decode(t, &body)
+	if len(body.Data) != 1 || body.Data[0].Slug != "acme" {
+		t.Fatalf("got %s", resp.Body)
+	}
+	if body.Data[0].Role != models.RoleOwner {
+		t.Errorf("got role %s, want owner", body.Data[0].Role)
+	}
+}
+
+func TestUpdateOrganizationRequiresOwnerOrAdmin(t *testing.T) {
+	env := testenv.NewMemory(t)
+
+	syncUser(t, env, "user_owner", "owner@example.com")
+	syncUser(t, env, "user_admin", "admin@example.com")
+	syncUser(t, env, "user_member", "member@example.com")
+	syncOrganization(t, env, "org_1", "acme", "user_owner")
+	syncMembership(t, env, "org_1", "user_admin", "org:admin")
+	syncMembership(t, env, "org_1", "user_member", "org:member")
+
+	update := map[string]any{"description": "Rockets and anvils"}
+
+	tests := []struct {
+		clerkUserID string
+		status      int
+	}{
+		{"user_member", http.StatusForbidden},
+		{"user_admin", http.StatusOK},
+		{"user_owner", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.clerkUserID, func(t *testing.T) {
+			resp := env.Do(t, http.MethodPatch, "/api/v1/organizations/acme", tt.clerkUserID, update)
+			expectStatus(t, resp, tt.status)
+		})
+	}
+}
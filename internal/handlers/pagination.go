@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"github.com/atavada/project-management-saas/internal/listing"
+	"github.com/atavada/project-management-saas/internal/models"
+	"github.com/gofiber/fiber/v3"
+)
+
+// listParams parses the page, cursor, sort and filter query parameters
+// allowed by spec
+func listParams(c fiber.Ctx, spec listing.Spec) (listing.Params, error) {
+	return listing.Parse(c.Queries(), spec)
+}
+
+// paginated wraps a page in the envelope matching how it was paged
+func paginated[T any](page *listing.Page[T], params listing.Params) any {
+	if params.Keyset {
+		return models.CursorPaginatedResponse{
+			Data:       page.Items,
+			Pagination: models.NewCursorMeta(params.Limit, page.Next, page.Prev),
+		}
+	}
+
+	return models.PaginatedResponse{
+		Data:       page.Items,
+		Pagination: models.NewPaginationMeta(params.Page, params.Limit, page.Total),
+	}
+}
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/atavada/project-management-saas/internal/apperror"
+	"github.com/atavada/project-management-saas/internal/models"
+	"github.com/atavada/project-management-saas/internal/repository"
+	"github.com/atavada/project-management-saas/internal/services"
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+)
+
+type TaskHandler struct {
+	userRepo    repository.UserRepository
+	memberRepo  repository.OrganizationMemberRepository
+	projectRepo repository.ProjectRepository
+	taskRepo    repository.TaskRepository
+	taskService *services.TaskService
+}
+
+func NewTaskHandler(
+	userRepo repository.UserRepository,
+	memberRepo repository.OrganizationMemberRepository,
+	projectRepo repository.ProjectRepository,
+	taskRepo repository.TaskRepository,
+	taskService *services.TaskService,
+) *TaskHandler {
+	return &TaskHandler{
+		userRepo:    userRepo,
+		memberRepo:  memberRepo,
+		projectRepo: projectRepo,
+		taskRepo:    taskRepo,
+		taskService: taskService,
+	}
+}
+
+// UpdateTask changes the status, assignee, priority, due date, title or
+// description of a task. Any member of the task's organization can
+func (h *TaskHandler) UpdateTask(c fiber.Ctx) error {
+	var req models.UpdateTaskRequest
+	if err := bindBody(c, &req); err != nil {
+		return err
+	}
+
+	task, user, err := h.authorizeMember(c)
+	if err != nil {
+		return err
+	}
+
+	updated, err := h.taskService.Update(c.Context(), task.ID, user.ID, &req)
+	if err != nil {
+		return wrapError("Failed to update task", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"data": updated,
+	})
+}
+
+// CreateComment adds a comment to the activity of a task
+func (h *TaskHandler) CreateComment(c fiber.Ctx) error {
+	var req models.CreateTaskCommentRequest
+	if err := bindBody(c, &req); err != nil {
+		return err
+	}
+
+	task, user, err := h.authorizeMember(c)
+	if err != nil {
+		return err
+	}
+
+	comment, err := h.taskService.Comment(c.Context(), task.ID, user.ID, req.Body)
+	if err != nil {
+		return wrapError("Failed to add comment", err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"data": comment,
+	})
+}
+
+// ListActivity returns a page of the history of a task, oldest first: status
+// changes, reassignments, priority and due date changes and comments, each
+// with the user who made it
+func (h *TaskHandler) ListActivity(c fiber.Ctx) error {
+	params, err := listParams(c, repository.TaskActivityListSpec)
+	if err != nil {
+		return err
+	}
+
+	task, _, err := h.authorizeMember(c)
+	if err != nil {
+		return err
+	}
+
+	page, err := h.taskService.Activity(c.Context(), task.ID, params)
+	if err != nil {
+		return wrapError("Failed to fetch task activity", err)
+	}
+
+	return c.JSON(paginated(page, params))
+}
+
+// authorizeMember loads the task in :id and the authenticated user, who must
+// be a member of the task's organization
+func (h *TaskHandler) authorizeMember(c fiber.Ctx) (*models.Task, *models.User, error) {
+	ctx := c.Context()
+
+	taskID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return nil, nil, apperror.BadRequest("Invalid task ID")
+	}
+
+	user, err := currentUser(c, h.userRepo)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	task, err := h.taskRepo.GetByID(ctx, taskID)
+	if err != nil {
+		return nil, nil, wrapError("Failed to fetch task", err)
+	}
+
+	project, err := h.projectRepo.GetByID(ctx, task.ProjectID)
+	if err != nil {
+		return nil, nil, apperror.Internal("Failed to fetch project", err)
+	}
+
+	// Tasks of other organizations are reported as missing, not forbidden, so
+	// task IDs can't be probed
+	_, err = h.memberRepo.GetMember(ctx, project.OrganizationID, user.ID)
+	if errors.Is(err, apperror.ErrNotFound) {
+		return nil, nil, apperror.NotFound("Task not found")
+	}
+	if err != nil {
+		return nil, nil, apperror.Internal("Failed to verify membership", err)
+	}
+
+	return task, user, nil
+}
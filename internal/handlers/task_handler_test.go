@@ -0,0 +1,168 @@
+package handlers_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/atavada/project-management-saas/internal/models"
+	"github.com/atavada/project-management-saas/internal/testenv"
+)
+
+// taskFixture is an organization with an owner, a member, a user outside of it
+// and one task
+type taskFixture struct {
+	env      *testenv.Env
+	owner    *models.User
+	member   *models.User
+	outsider *models.User
+	task     *models.Task
+	path     string
+}
+
+func newTaskFixture(t *testing.T) *taskFixture {
+	t.Helper()
+
+	env := testenv.NewMemory(t)
+
+	owner := syncUser(t, env, "user_owner", "owner@example.com")
+	member := syncUser(t, env, "user_member", "member@example.com")
+	outsider := syncUser(t, env, "user_outsider", "outsider@example.com")
+	org := syncOrganization(t, env, "org_1", "acme", "user_owner")
+	syncMembership(t, env, "org_1", "user_member", "org:member")
+	task := createTask(t, env, org.ID, owner.ID)
+
+	return &taskFixture{
+		env:      env,
+		owner:    owner,
+		member:   member,
+		outsider: outsider,
+		task:     task,
+		path:     "/api/v1/tasks/" + task.ID.String(),
+	}
+}
+
+func TestUpdateTaskRecordsActivity(t *testing.T) {
+	f := newTaskFixture(t)
+
+	resp := f.env.Do(t, http.MethodPatch, f.path, "user_member", map[string]any{
+		"status":      "done",
+		"assigned_to": f.member.ID,
+	})
+	expectStatus(t, resp, http.StatusOK)
+
+	var updated struct {
+		Data models.Task `json:"data"`
+	}
+	resp.Decode(t, &updated)
+	if updated.Data.Status != models.TaskStatusDone || updated.Data.CompletedAt == nil {
+		t.Errorf("got status %s completed at %v, want done with a completion time", updated.Data.Status, updated.Data.CompletedAt)
+	}
+
+	resp = f.env.Do(t, http.MethodGet, f.path+"/activity", "user_owner", nil)
+	expectStatus(t, resp, http.StatusOK)
+
+	var activity struct {
+		Data []models.TaskActivity `json:"data"`
+	}
+	resp.Decode(t, &activity)
+	if len(activity.Data) != 2 {
+		t.Fatalf("got %d activity entries, want 2: %s", len(activity.Data), resp.Body)
+	}
+
+	status := activity.Data[0]
+	if status.Type != models.TaskActivityStatusChanged || *status.From != "todo" || *status.To != "done" {
+		t.Errorf("got %s from %v to %v, want status_changed from todo to done", status.Type, status.From, status.To)
+	}
+	if status.Actor == nil || status.Actor.ID != f.member.ID {
+		t.Errorf("got actor %+v, want the member", status.Actor)
+	}
+
+	reassigned := activity.Data[1]
+	if reassigned.Type != models.TaskActivityReassigned || reassigned.From != nil || *reassigned.To != f.member.ID.String() {
+		t.Errorf("got %s from %v to %v, want reassigned to the member", reassigned.Type, reassigned.From, reassigned.To)
+	}
+}
+
+func TestUpdateTaskWithoutChangesRecordsNothing(t *testing.T) {
+	f := newTaskFixture(t)
+
+	resp := f.env.Do(t, http.MethodPatch, f.path, "user_member", map[string]any{"status": "todo"})
+	expectStatus(t, resp, http.StatusOK)
+
+	resp = f.env.Do(t, http.MethodGet, f.path+"/activity", "user_member", nil)
+	expectStatus(t, resp, http.StatusOK)
+
+	var activity struct {
+		Data []models.TaskActivity `json:"data"`
+	}
+	resp.Decode(t, &activity)
+	if len(activity.Data) != 0 {
+		t.Errorf("got %d activity entries, want none: %s", len(activity.Data), resp.Body)
+	}
+}
+
+func TestUpdateTaskRejectsAssigneeOutsideOrganization(t *testing.T) {
+	f := newTaskFixture(t)
+
+	resp := f.env.Do(t, http.MethodPatch, f.path, "user_member", map[string]any{"assigned_to": f.outsider.ID})
+	expectStatus(t, resp, http.StatusBadRequest)
+
+	var body models.ErrorResponse
+	resp.Decode(t, &body)
+	if len(body.Fields) != 1 || body.Fields[0].Field != "assigned_to" {
+		t.Errorf("got fields %+v, want assigned_to", body.Fields)
+	}
+}
+
+func TestCreateComment(t *testing.T) {
+	f := newTaskFixture(t)
+
+	resp := f.env.Do(t, http.MethodPost, f.path+"/comments", "user_member", map[string]any{"body": "Draft is in the doc"})
+	expectStatus(t, resp, http.StatusCreated)
+
+	var comment struct {
+		Data models.TaskActivity `json:"data"`
+	}
+	resp.Decode(t, &comment)
+	if comment.Data.Type != models.TaskActivityCommented || comment.Data.Body == nil || *comment.Data.Body != "Draft is in the doc" {
+		t.Errorf("got %s", resp.Body)
+	}
+
+	resp = f.env.Do(t, http.MethodPost, f.path+"/comments", "user_member", map[string]any{"body": ""})
+	expectStatus(t, resp, http.StatusBadRequest)
+}
+
+func TestTaskAccess(t *testing.T) {
+	f := newTaskFixture(t)
+
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		clerkUserID string
+		body        any
+		status      int
+	}{
+		{"outsider updates", http.MethodPatch, f.path, "user_outsider", map[string]any{"status": "done"}, http.StatusNotFound},
+		{"outsider comments", http.MethodPost, f.path + "/comments", "user_outsider", map[string]any{"body": "Hi"}, http.StatusNotFound},
+		{"outsider reads activity", http.MethodGet, f.path + "/activity", "user_outsider", nil, http.StatusNotFound},
+		{"unknown task", http.MethodGet, "/api/v1/tasks/00000000-0000-0000-0000-000000000001/activity", "user_member", nil, http.StatusNotFound},
+		{"invalid task ID", http.MethodGet, "/api/v1/tasks/not-a-uuid/activity", "user_member", nil, http.StatusBadRequest},
+		{"user not synced yet", http.MethodGet, f.path + "/activity", "user_unknown", nil, http.StatusUnauthorized},
+		{"no token", http.MethodGet, f.path + "/activity", "", nil, http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expectStatus(t, f.env.Do(t, tt.method, tt.path, tt.clerkUserID, tt.body), tt.status)
+		})
+	}
+
+	task, err := f.env.Store.Tasks().GetByID(t.Context(), f.task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if task.Status != models.TaskStatusTodo {
+		t.Errorf("an outsider changed the task to %s", task.Status)
+	}
+}
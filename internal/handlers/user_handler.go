@@ -1,40 +1,109 @@
 package handlers
 
 import (
-	"context"
+	"strings"
 
+	"github.com/atavada/project-management-saas/internal/apperror"
+	"github.com/atavada/project-management-saas/internal/models"
 	"github.com/atavada/project-management-saas/internal/repository"
 	"github.com/gofiber/fiber/v3"
 )
 
 type UserHandler struct {
-	userRepo *repository.UserRepository
+	userRepo repository.UserRepository
+	prefsRepo repository.UserPreferencesRepository
 }
 
-func NewUserHandler(userRepo *repository.UserRepository) *UserHandler {
+func NewUserHandler(
+	userRepo repository.UserRepository,
+	prefsRepo repository.UserPreferencesRepository,
+) *UserHandler {
 	return &UserHandler{
 		userRepo: userRepo,
+		prefsRepo: prefsRepo,
 	}
 }
 
 // GetCurrentUser returns the authenticated user's profile
 func (h *UserHandler) GetCurrentUser(c fiber.Ctx) error {
-	ctx := context.Background()
-	clerkUserID := c.Locals("clerkUserID").(string)
+	ctx := c.Context()
 
-	user, err := h.userRepo.GetByClerkID(ctx, clerkUserID)
+	user, err := currentUser(c, h.userRepo)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to fetch user",
-		})
+		return err
 	}
-	if user == nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": "User not found",
-		})
+
+	prefs, err := h.prefsRepo.Get(ctx, user.ID)
+	if err != nil {
+		return apperror.Internal("Failed to fetch preferences", err)
+	}
+	if prefs == nil {
+		prefs = models.DefaultUserPreferences(user.ID)
+	}
+
+	return c.JSON(fiber.Map{
+		"data": models.UserProfile{User: *user, Preferences: prefs},
+	})
+}
+
+// UpdateCurrentUser updates the app-owned preferences of the authenticated user
+func (h *UserHandler) UpdateCurrentUser(c fiber.Ctx) error {
+	ctx := c.Context()
+
+	var req models.UpdateUserPreferencesRequest
+	if err := bindBody(c, &req); err != nil {
+		return err
+	}
+
+	user, err := currentUser(c, h.userRepo)
+	if err != nil {
+		return err
+	}
+
+	prefs, err := h.prefsRepo.Get(ctx, user.ID)
+	if err != nil {
+		return apperror.Internal("Failed to fetch preferences", err)
+	}
+	if prefs == nil {
+		prefs = models.DefaultUserPreferences(user.ID)
+	}
+
+	// Apply the partial update on top of the stored values
+	if req.Timezone != nil {
+		prefs.Timezone = *req.Timezone
+	}
+	if req.Locale != nil {
+		prefs.Locale = *req.Locale
+	}
+	if req.DisplayName != nil {
+		displayName := strings.TrimSpace(*req.DisplayName)
+		if displayName == "" {
+			prefs.DisplayName = nil
+		} else {
+			prefs.DisplayName = &displayName
+		}
+	}
+	if n := req.Notifications; n != nil {
+		if n.TaskAssigned != nil {
+			prefs.Notifications.TaskAssigned = *n.TaskAssigned
+		}
+		if n.TaskDueSoon != nil {
+			prefs.Notifications.TaskDueSoon = *n.TaskDueSoon
+		}
+		if n.TaskCommented != nil {
+			prefs.Notifications.TaskCommented = *n.TaskCommented
+		}
+		if n.WeeklyDigest != nil {
+			prefs.Notifications.WeeklyDigest = *n.WeeklyDigest
+		}
+	}
+
+	updated, err := h.prefsRepo.Upsert(ctx, prefs)
+	if err != nil {
+		return apperror.Internal("Failed to update preferences", err)
 	}
 
 	return c.JSON(fiber.Map{
-		"data": user,
+		"data": models.UserProfile{User: *user, Preferences: updated},
 	})
-}
\ No newline at end of file
+}
@@ -3,39 +3,56 @@ package handlers
 import (
 	"context"
 	"encoding/json"
-	"log"
+	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
 
+	"github.com/atavada/project-management-saas/internal/apperror"
+	"github.com/atavada/project-management-saas/internal/audit"
+	"github.com/atavada/project-management-saas/internal/database"
+	"github.com/atavada/project-management-saas/internal/logging"
+	"github.com/atavada/project-management-saas/internal/metrics"
 	"github.com/atavada/project-management-saas/internal/models"
 	"github.com/atavada/project-management-saas/internal/repository"
+	"github.com/atavada/project-management-saas/internal/validation"
 	"github.com/gofiber/fiber/v3"
 	svix "github.com/svix/svix-webhooks/go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
+var tracer = otel.Tracer("github.com/atavada/project-management-saas/internal/handlers")
+
 type WebhookHandler struct {
-	userRepo 		*repository.UserRepository
-	orgRepo  		*repository.OrganizationRepository
-	memberRepo 	*repository.OrganizationMemberRepository
+	userRepo 		repository.UserRepository
+	orgRepo  		repository.OrganizationRepository
+	memberRepo 	repository.OrganizationMemberRepository
+	tx 					database.Transactor
 	webhookSecret string
 }
 
 func NewWebhookHandler(
-	userRepo *repository.UserRepository,
-	orgRepo *repository.OrganizationRepository,
-	memberRepo *repository.OrganizationMemberRepository,
+	userRepo repository.UserRepository,
+	orgRepo repository.OrganizationRepository,
+	memberRepo repository.OrganizationMemberRepository,
+	tx database.Transactor,
 	webhookSecret string,
 ) *WebhookHandler {
 	return &WebhookHandler{
 		userRepo:      userRepo,
 		orgRepo:       orgRepo,
 		memberRepo:    memberRepo,
+		tx:            tx,
 		webhookSecret: webhookSecret,
 	}
 }
 
 // HandlerClerkWebhook processes incoming Clerk webhooks
 func (h *WebhookHandler) HandlerClerkWebhook(c fiber.Ctx) error {
-	ctx := context.Background()
+	ctx := c.Context()
 
 	// Get Svix headers for verif
 	svixID := c.Get("svix-Id")
@@ -43,19 +60,14 @@ func (h *WebhookHandler) HandlerClerkWebhook(c fiber.Ctx) error {
 	svixSignature := c.Get("svix-signature")
 
 	if svixID == "" || svixTimeStamp == "" || svixSignature == "" {
-		log.Println("Missing svix headers")
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Missing webhook headers",
-		})
+		slog.WarnContext(ctx, "Missing svix headers")
+		return apperror.BadRequest("Missing webhook headers")
 	}
 
 	// Verify webhook signature
 	webhooks, err := svix.NewWebhook(h.webhookSecret)
 	if err != nil {
-		log.Printf("Error creating webhook verifier: %v", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Webhook verifier error",
-		})
+		return apperror.Internal("Webhook verifier error", err)
 	}
 
 	payload := c.Body()
@@ -67,53 +79,70 @@ func (h *WebhookHandler) HandlerClerkWebhook(c fiber.Ctx) error {
 	var event map[string]interface{}
 	err = webhooks.Verify(payload, headers)
 	if err != nil {
-		log.Printf("Webhook verification failed: %v", err)
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": "Invalid webhook signature",
-		})
+		slog.WarnContext(ctx, "Webhook verification failed", "error", err)
+		return apperror.Unauthorized("Invalid webhook signature")
 	}
 
 	// Parse the event
 	if err := json.Unmarshal(payload, &event); err != nil {
-		log.Printf("Error parsing webhook payload: %v", err)
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid JSON",
-		})
+		slog.WarnContext(ctx, "Error parsing webhook payload", "error", err)
+		return apperror.BadRequest("Invalid JSON")
 	}
 
 	eventType, ok := event["type"].(string)
 	if !ok {
-		log.Println("Missing event type")
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Missing event type",
-		})
+		slog.WarnContext(ctx, "Missing event type")
+		return apperror.BadRequest("Missing event type")
 	}
 
 	data := event["data"]
 
 	// Route to appropriate handler
-	log.Printf("Processing webhook event: %s", eventType)
+	ctx = logging.With(ctx, "webhook_id", svixID, "event_type", eventType)
+	ctx = audit.WithActor(ctx, audit.Webhook("clerk"))
+	slog.InfoContext(ctx, "Processing webhook event")
 
+	var handle func(ctx context.Context, data interface{}) error
 	switch eventType {
 	case "user.created", "user.updated":
-			return h.handleUserEvent(ctx, c, data)
+		handle = h.handleUserEvent
+	case "user.deleted":
+		handle = h.handleUserDeleted
 	case "organization.created", "organization.updated":
-			return h.handleOrganizationEvent(ctx, c, data)
+		handle = h.handleOrganizationEvent
 	case "organizationMembership.created":
-			return h.handleMembershipCreated(ctx, c, data)
+		handle = h.handleMembershipCreated
 	case "organizationMembership.deleted":
-			return h.handleMembershipDeleted(ctx, c, data)
+		handle = h.handleMembershipDeleted
 	default:
-			log.Printf("Unhandled webhook event type: %s", eventType)
-			return c.SendStatus(fiber.StatusOK)
+		slog.InfoContext(ctx, "Unhandled webhook event type")
+		metrics.WebhookEventsIgnored.WithLabelValues(eventType).Inc()
+		return c.SendStatus(fiber.StatusOK)
+	}
+
+	ctx, span := tracer.Start(ctx, "webhook "+eventType, trace.WithAttributes(
+		attribute.String("webhook.source", "clerk"),
+		attribute.String("webhook.event_type", eventType),
+		attribute.String("webhook.id", svixID),
+	))
+	defer span.End()
+
+	if err := handle(ctx, data); err != nil {
+		slog.ErrorContext(ctx, "Error processing webhook event", "error", err)
+		metrics.WebhookEventsFailed.WithLabelValues(eventType).Inc()
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "webhook event failed")
+		return c.SendStatus(fiber.StatusOK) // return 200 to avoid retries
 	}
+
+	metrics.WebhookEventsProcessed.WithLabelValues(eventType).Inc()
+	return c.SendStatus(fiber.StatusOK)
 }
 
-func (h *WebhookHandler) handleUserEvent(ctx context.Context, c fiber.Ctx, data interface{}) error {
+func (h *WebhookHandler) handleUserEvent(ctx context.Context, data interface{}) error {
 	userData, ok := data.(map[string]interface{})
 	if !ok {
-		log.Println("Invalid user data format")
-		return c.SendStatus(fiber.StatusOK)
+		return errors.New("invalid user data format")
 	}
 
 	// Extract user info
@@ -131,11 +160,6 @@ func (h *WebhookHandler) handleUserEvent(ctx context.Context, c fiber.Ctx, data
 	lastName, _ := userData["last_name"].(string)
 	imageURL, _ := userData["image_url"].(string)
 
-	if clerkUserID == "" || email == "" {
-		log.Println("Missing required user fields")
-		return c.SendStatus(fiber.StatusOK)
-	}
-
 	// Upsert user to DB
 	user := &models.CreateUserRequest{
 		ClerkUserID: clerkUserID,
@@ -145,21 +169,55 @@ func (h *WebhookHandler) handleUserEvent(ctx context.Context, c fiber.Ctx, data
 		AvatarURL:   imageURL,
 	}
 
+	if err := validation.Struct(user); err != nil {
+		return fmt.Errorf("invalid user payload: %w", err)
+	}
+
 	_, err := h.userRepo.Upsert(ctx, user)
 	if err != nil {
-		log.Printf("Error upserting user: %v", err)
-		return c.SendStatus(fiber.StatusOK) // return 200 to avoid retries
+		return fmt.Errorf("error upserting user: %w", err)
 	}
 
-	log.Printf("User synced: %s (%s)", email, clerkUserID)
-	return c.SendStatus(fiber.StatusOK)
+	slog.InfoContext(ctx, "User synced", "clerk_user_id", clerkUserID)
+	return nil
+}
+
+// handleUserDeleted anonymizes the user instead of deleting the row, deleting
+// would cascade into the tasks they created
+func (h *WebhookHandler) handleUserDeleted(ctx context.Context, data interface{}) error {
+	userData, ok := data.(map[string]interface{})
+	if !ok {
+		return errors.New("invalid user data format")
+	}
+
+	clerkUserID, _ := userData["id"].(string)
+	if clerkUserID == "" {
+		return errors.New("missing required user fields for deletion")
+	}
+
+	// Users erased through the admin API are anonymized before Clerk reports
+	// the deletion, their Clerk ID is gone by then
+	user, err := h.userRepo.GetByClerkID(ctx, clerkUserID)
+	if errors.Is(err, apperror.ErrNotFound) {
+		slog.InfoContext(ctx, "Deleted user not found, already erased", "clerk_user_id", clerkUserID)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error getting user %s: %w", clerkUserID, err)
+	}
+
+	if _, err := h.userRepo.Anonymize(ctx, user.ID); err != nil {
+		return fmt.Errorf("error anonymizing user: %w", err)
+	}
+
+	slog.InfoContext(ctx, "User anonymized", "user_id", user.ID)
+	return nil
 }
 
-func (h *WebhookHandler) handleOrganizationEvent(ctx context.Context, c fiber.Ctx, data interface{}) error {
+func (h *WebhookHandler) handleOrganizationEvent(ctx context.Context, data interface{}) error {
 	orgData, ok := data.(map[string]interface{})
 	if !ok {
-		log.Println("Invalid organization data format")
-		return c.SendStatus(fiber.StatusOK)
+		return errors.New("invalid organization data format")
 	}
 
 	clerkOrgID, _ := orgData["id"].(string)
@@ -168,11 +226,6 @@ func (h *WebhookHandler) handleOrganizationEvent(ctx context.Context, c fiber.Ct
 	imageURL, _ := orgData["image_url"].(string)
 	createdBy, _ := orgData["created_by"].(string)
 
-	if clerkOrgID == "" || name == "" || slug == "" {
-		log.Println("Missing required organization fields")
-		return c.SendStatus(fiber.StatusOK)
-	}
-
 	// Upsert organization
 	org := &models.CreateOrganizationRequest{
 		ClerkOrgID: clerkOrgID,
@@ -182,39 +235,72 @@ func (h *WebhookHandler) handleOrganizationEvent(ctx context.Context, c fiber.Ct
 		LogoURL: imageURL,
 	}
 
-	createdOrg, err := h.orgRepo.Upsert(ctx, org)
-	if err != nil {
-		log.Printf("Error upserting organization: %v", err)
-		return c.SendStatus(fiber.StatusOK)
+	if err := validation.Struct(org); err != nil {
+		return fmt.Errorf("invalid organization payload: %w", err)
 	}
 
-	// For new organizations, add creator as owner
-	if createdBy != "" {
-		creator, err := h.userRepo.GetByClerkID(ctx, createdBy)
-		if err == nil && creator != nil {
-			member := &models.OrganizationMember{
-				OrganizationID: createdOrg.ID,
-				UserID: creator.ID,
-				Role: models.RoleOwner,
-			}
+	// The org, its slug history and the owner membership are written together
+	// so a failure cannot leave an ownerless organization behind
+	var createdOrg *models.Organization
+	err := h.tx.WithTx(ctx, func(ctx context.Context) error {
+		// Look up the current slug so a rename can be recorded
+		existingOrg, err := h.orgRepo.GetByClerkID(ctx, clerkOrgID)
+		if err != nil && !errors.Is(err, apperror.ErrNotFound) {
+			return fmt.Errorf("error getting organization: %w", err)
+		}
+
+		createdOrg, err = h.orgRepo.Upsert(ctx, org)
+		if err != nil {
+			return fmt.Errorf("error upserting organization: %w", err)
+		}
 
-			if err := h.memberRepo.Create(ctx, member); err != nil {
-				log.Printf("Error creating owner membership: %v", err)
-			} else {
-				log.Printf("Owner memberhsip created for org: %s", name)
+		// Keep old slugs resolvable after a rename in Clerk
+		if existingOrg != nil && existingOrg.Slug != createdOrg.Slug {
+			if err := h.orgRepo.RecordSlugChange(ctx, createdOrg.ID, existingOrg.Slug); err != nil {
+				return fmt.Errorf("error recording slug change: %w", err)
 			}
 		}
+
+		// For new organizations, add creator as owner. A creator who is not
+		// synced yet gets the membership from its own webhook
+		if createdBy == "" {
+			return nil
+		}
+
+		creator, err := h.userRepo.GetByClerkID(ctx, createdBy)
+		if errors.Is(err, apperror.ErrNotFound) {
+			slog.WarnContext(ctx, "Organization creator not found", "clerk_user_id", createdBy)
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("error getting organization creator: %w", err)
+		}
+
+		member := &models.OrganizationMember{
+			OrganizationID: createdOrg.ID,
+			UserID:         creator.ID,
+			Role:           models.RoleOwner,
+		}
+
+		if err := h.memberRepo.Create(ctx, member); err != nil {
+			return fmt.Errorf("error creating owner membership: %w", err)
+		}
+
+		slog.InfoContext(ctx, "Owner membership created", "org_id", createdOrg.ID, "user_id", creator.ID)
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
-	log.Printf("Organization synced: %s (%s)", name, clerkOrgID)
-	return c.SendStatus(fiber.StatusOK)
+	slog.InfoContext(ctx, "Organization synced", "org_id", createdOrg.ID, "clerk_org_id", clerkOrgID)
+	return nil
 }
 
-func (h *WebhookHandler) handleMembershipCreated(ctx context.Context, c fiber.Ctx, data interface{}) error {
+func (h *WebhookHandler) handleMembershipCreated(ctx context.Context, data interface{}) error {
 	membershipData, ok := data.(map[string]interface{})
 	if !ok {
-		log.Println("Invalid membership data format")
-		return c.SendStatus(fiber.StatusOK)
+		return errors.New("invalid membership data format")
 	}
 
 	clerkMembershipID, _ := membershipData["id"].(string)
@@ -231,33 +317,28 @@ func (h *WebhookHandler) handleMembershipCreated(ctx context.Context, c fiber.Ct
 	role, _ := membershipData["role"].(string)
 
 	if clerkOrgID == "" || clerkUserID == "" {
-		log.Println("Missing required membership fields")
-		return c.SendStatus(fiber.StatusOK)
+		return errors.New("missing required membership fields")
 	}
 
 	// Get local org and user IDs
 	org, err := h.orgRepo.GetByClerkID(ctx, clerkOrgID)
-	if err != nil || org == nil {
-		log.Printf("Organization not found: %s", clerkOrgID)
-		return c.SendStatus(fiber.StatusOK)
+	if err != nil {
+		return fmt.Errorf("error getting organization %s: %w", clerkOrgID, err)
 	}
 
+	// Users erased through the admin API are anonymized before Clerk reports
+	// the deletion, their Clerk ID is gone by then
 	user, err := h.userRepo.GetByClerkID(ctx, clerkUserID)
-	if err != nil || user == nil {
-		log.Printf("User not found: %s", clerkUserID)
-		return c.SendStatus(fiber.StatusOK)
+	if errors.Is(err, apperror.ErrNotFound) {
+		slog.InfoContext(ctx, "Deleted user not found, already erased", "clerk_user_id", clerkUserID)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error getting user %s: %w", clerkUserID, err)
 	}
 
 	// Map clerk role to our role
-	var memberRole models.OrganizationRole
-	switch role {
-	case "admin":
-		memberRole = models.RoleAdmin
-	case "basic_member":
-		memberRole = models.RoleMember
-	default:
-		memberRole = models.RoleMember
-	}
+	memberRole := models.RoleFromClerk(role)
 
 	// Create membership
 	member := &models.OrganizationMember{
@@ -268,19 +349,17 @@ func (h *WebhookHandler) handleMembershipCreated(ctx context.Context, c fiber.Ct
 	}
 
 	if err := h.memberRepo.Create(ctx, member); err != nil {
-		log.Printf("Error creating membership: %v", err)
-		return c.SendStatus(fiber.StatusOK)
+		return fmt.Errorf("error creating membership: %w", err)
 	}
 
-	log.Printf("Membership created: %s in %s", user.Email, org.Name)
-	return c.SendStatus(fiber.StatusOK)
+	slog.InfoContext(ctx, "Membership created", "org_id", org.ID, "user_id", user.ID)
+	return nil
 }
 
-func (h *WebhookHandler) handleMembershipDeleted(ctx context.Context, c fiber.Ctx, data interface{}) error {
+func (h *WebhookHandler) handleMembershipDeleted(ctx context.Context, data interface{}) error {
 	membershipData, ok := data.(map[string]interface{})
 	if !ok {
-		log.Println("Invalid membership data format")
-		return c.SendStatus(fiber.StatusOK)
+		return errors.New("invalid membership data format")
 	}
 
 	var clerkOrgID, clerkUserID string
@@ -292,30 +371,32 @@ func (h *WebhookHandler) handleMembershipDeleted(ctx context.Context, c fiber.Ct
 	}
 	
 	if clerkOrgID == "" || clerkUserID == "" {
-		log.Println("Missing required membership fields for deletion")
-		return c.SendStatus(fiber.StatusOK)
+		return errors.New("missing required membership fields for deletion")
 	}
 
 	// Get local org and user IDs
 	org, err := h.orgRepo.GetByClerkID(ctx, clerkOrgID)
-	if err != nil || org == nil {
-		log.Printf("Organization not found: %s", clerkOrgID)
-		return c.SendStatus(fiber.StatusOK)
+	if err != nil {
+		return fmt.Errorf("error getting organization %s: %w", clerkOrgID, err)
 	}
 
+	// Users erased through the admin API are anonymized before Clerk reports
+	// the deletion, their Clerk ID is gone by then
 	user, err := h.userRepo.GetByClerkID(ctx, clerkUserID)
-	if err != nil || user == nil {
-		log.Printf("User not found: %s", clerkUserID)
-		return c.SendStatus(fiber.StatusOK)
+	if errors.Is(err, apperror.ErrNotFound) {
+		slog.InfoContext(ctx, "Deleted user not found, already erased", "clerk_user_id", clerkUserID)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error getting user %s: %w", clerkUserID, err)
 	}
 
 	// Delete membership
 	if err := h.memberRepo.Delete(ctx, org.ID, user.ID); err != nil {
-		log.Printf("Error deleting membership: %v", err)
-		return c.SendStatus(fiber.StatusOK)
+		return fmt.Errorf("error deleting membership: %w", err)
 	}
 	
-	log.Printf("Membership deleted: %s from %s", user.Email, org.Name)
-	return c.SendStatus(fiber.StatusOK)
+	slog.InfoContext(ctx, "Membership deleted", "org_id", org.ID, "user_id", user.ID)
+	return nil
 }
 
@@ -0,0 +1,162 @@
+package handlers_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/atavada/project-management-saas/internal/apperror"
+	"github.com/atavada/project-management-saas/internal/models"
+	"github.com/atavada/project-management-saas/internal/testenv"
+)
+
+func TestClerkWebhookSyncsUser(t *testing.T) {
+	env := testenv.NewMemory(t)
+
+	syncUser(t, env, "user_1", "ada@example.com")
+
+	resp := env.Do(t, http.MethodGet, "/api/v1/users/me", "user_1", nil)
+	expectStatus(t, resp, http.StatusOK)
+
+	var body struct {
+		Data models.UserProfile `json:"data"`
+	}
+	resp.Decode(t, &body)
+	if body.Data.Email != "ada@example.com" {
+		t.Errorf("got email %q, want ada@example.com", body.Data.Email)
+	}
+}
+
+func TestClerkWebhookRejectsUnverifiedDeliveries(t *testing.T) {
+	tests := []struct {
+		name    string
+		request func(env *testenv.Env) *http.Request
+		status  int
+	}{
+		{
+			name: "wrong secret",
+			request: func(env *testenv.Env) *http.Request {
+				data := testenv.UserData("user_1", "ada@example.com", "Ada", "Lovelace")
+				return env.Webhooks.Event("user.created", data).SignedWith("whsec_b3RoZXItc2lnbmluZy1zZWNyZXQ=").Request(t)
+			},
+			status: http.StatusUnauthorized,
+		},
+		{
+			name: "stale timestamp",
+			request: func(env *testenv.Env) *http.Request {
+				data := testenv.UserData("user_1", "ada@example.com", "Ada", "Lovelace")
+				return env.Webhooks.Event("user.created", data).At(time.Now().Add(-time.Hour)).Request(t)
+			},
+			status: http.StatusUnauthorized,
+		},
+		{
+			name: "missing headers",
+			request: func(env *testenv.Env) *http.Request {
+				req := env.Webhooks.Event("user.created", testenv.UserData("user_1", "ada@example.com", "Ada", "Lovelace")).Request(t)
+				req.Header.Del("svix-signature")
+				return req
+			},
+			status: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := testenv.NewMemory(t)
+
+			expectStatus(t, env.Send(t, tt.request(env)), tt.status)
+
+			_, err := env.Store.Users().GetByClerkID(context.Background(), "user_1")
+			if !errors.Is(err, apperror.ErrNotFound) {
+				t.Errorf("rejected delivery synced the user, lookup returned %v", err)
+			}
+		})
+	}
+}
+
+func TestClerkWebhookSyncsOrganizationAndMemberships(t *testing.T) {
+	env := testenv.NewMemory(t)
+
+	owner := syncUser(t, env, "user_owner", "owner@example.com")
+	admin := syncUser(t, env, "user_admin", "admin@example.com")
+	org := syncOrganization(t, env, "org_1", "acme", "user_owner")
+	syncMembership(t, env, "org_1", "user_admin", "org:admin")
+
+	ctx := context.Background()
+	for _, tt := range []struct {
+		user *models.User
+		role models.OrganizationRole
+	}{
+		{owner, models.RoleOwner},
+		{admin, models.RoleAdmin},
+	} {
+		member, err := env.Store.Members().GetMember(ctx, org.ID, tt.user.ID)
+		if err != nil {
+			t.Fatalf("membership of %s: %v", tt.user.Email, err)
+		}
+		if member.Role != tt.role {
+			t.Errorf("%s has role %s, want %s", tt.user.Email, member.Role, tt.role)
+		}
+	}
+
+	data := testenv.MembershipData("orgmem_user_admin", "org_1", "user_admin", "org:admin")
+	deliver(t, env, env.Webhooks.Event("organizationMembership.deleted", data))
+
+	resp := env.Do(t, http.MethodGet, "/api/v1/organizations/acme", "user_admin", nil)
+	expectStatus(t, resp, http.StatusForbidden)
+}
+
+func TestClerkWebhookRecordsSlugChange(t *testing.T) {
+	env := testenv.NewMemory(t)
+
+	syncUser(t, env, "user_owner", "owner@example.com")
+	syncOrganization(t, env, "org_1", "acme", "user_owner")
+	deliver(t, env, env.Webhooks.Event("organization.updated", testenv.OrganizationData("org_1", "Acme", "acme-inc", "user_owner")))
+
+	resp := env.Do(t, http.MethodGet, "/api/v1/organizations/acme/members?limit=2", "user_owner", nil)
+	expectStatus(t, resp, http.StatusPermanentRedirect)
+
+	if location := resp.Header.Get("Location"); location != "/api/v1/organizations/acme-inc/members?limit=2" {
+		t.Errorf("redirected to %q", location)
+	}
+}
+
+func TestClerkWebhookUserDeletedAnonymizesAndAuditsMemberships(t *testing.T) {
+	env := testenv.NewMemory(t)
+
+	syncUser(t, env, "user_owner", "owner@example.com")
+	member := syncUser(t, env, "user_member", "member@example.com")
+	syncOrganization(t, env, "org_1", "acme", "user_owner")
+	syncMembership(t, env, "org_1", "user_member", "org:member")
+
+	deliver(t, env, env.Webhooks.Event("user.deleted", testenv.DeletedUserData("user_member")))
+
+	anonymized, err := env.Store.Users().GetByID(context.Background(), member.ID)
+	if err != nil {
+		t.Fatalf("anonymized user: %v", err)
+	}
+	if anonymized.Email == member.Email {
+		t.Errorf("email %q was kept", anonymized.Email)
+	}
+
+	resp := env.Do(t, http.MethodGet, "/api/v1/users/me", "user_member", nil)
+	expectStatus(t, resp, http.StatusUnauthorized)
+
+	// The removed membership shows up in the organization's audit log
+	path := "/api/v1/organizations/acme/audit-log?entity_type=organization_member&action=deleted"
+	resp = env.Do(t, http.MethodGet, path, "user_owner", nil)
+	expectStatus(t, resp, http.StatusOK)
+
+	var body struct {
+		Data []models.AuditEvent `json:"data"`
+	}
+	resp.Decode(t, &body)
+	if len(body.Data) != 1 {
+		t.Fatalf("got %d membership deletions in the audit log, want 1: %s", len(body.Data), resp.Body)
+	}
+	if body.Data[0].ActorType != models.AuditActorWebhook {
+		t.Errorf("deletion was made by %s, want webhook", body.Data[0].ActorType)
+	}
+}
@@ -0,0 +1,58 @@
+package listing
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+// Cursor marks a position in a keyset paginated list: the sort key and
+// tiebreak of the row next to the page it leads to. Clients get it encoded and
+// pass it back as is
+type Cursor struct {
+	Sort string `json:"s"`
+	Desc bool   `json:"d,omitempty"`
+	// Value is the sort key as Postgres renders it in text, nil for NULL
+	Value *string `json:"v"`
+	ID    string  `json:"id"`
+	// Before asks for the page preceding the row instead of following it
+	Before bool `json:"b,omitempty"`
+}
+
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor reverses Encode. Anything it can't read is reported the same
+// way, a cursor is opaque to clients
+func DecodeCursor(s string) (*Cursor, error) {
+	invalid := errors.New("is not a valid cursor")
+
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, invalid
+	}
+
+	var c Cursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, invalid
+	}
+	if _, err := uuid.Parse(c.ID); err != nil || c.Sort == "" {
+		return nil, invalid
+	}
+
+	return &c, nil
+}
+
+// Page is one page of a list. Total is only counted for page/limit
+// pagination; keyset pages link to their neighbours through Next and Prev,
+// which are empty at either end
+type Page[T any] struct {
+	Items []T
+	Total int64
+	Next  string
+	Prev  string
+}
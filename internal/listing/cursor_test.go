@@ -0,0 +1,66 @@
+package listing_test
+
+import (
+	"encoding/base64"
+	"reflect"
+	"testing"
+
+	"github.com/atavada/project-management-saas/internal/listing"
+)
+
+const cursorID = "5f0c8d6e-3b7a-4d2e-9c1f-8a6b4e2d7c90"
+
+func TestCursorRoundTrip(t *testing.T) {
+	due := "2026-03-02"
+
+	tests := []struct {
+		name   string
+		cursor listing.Cursor
+	}{
+		{"ascending", listing.Cursor{Sort: "due_date", Value: &due, ID: cursorID}},
+		{"descending", listing.Cursor{Sort: "due_date", Desc: true, Value: &due, ID: cursorID}},
+		{"before", listing.Cursor{Sort: "due_date", Value: &due, ID: cursorID, Before: true}},
+		{"null value", listing.Cursor{Sort: "due_date", ID: cursorID}},
+		{"empty value", listing.Cursor{Sort: "title", Value: new(string), ID: cursorID}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := listing.DecodeCursor(tt.cursor.Encode())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(*got, tt.cursor) {
+				t.Errorf("got %+v, want %+v", *got, tt.cursor)
+			}
+		})
+	}
+}
+
+func TestDecodeCursorRejectsTampering(t *testing.T) {
+	encode := func(json string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(json))
+	}
+	valid := listing.Cursor{Sort: "due_date", ID: cursorID}.Encode()
+
+	tests := []struct {
+		name   string
+		cursor string
+	}{
+		{"not base64", "not a cursor!"},
+		{"truncated", valid[:len(valid)-3]},
+		{"not JSON", encode("title," + cursorID)},
+		{"wrong types", encode(`{"s":"title","v":1,"id":"` + cursorID + `"}`)},
+		{"missing sort", encode(`{"v":null,"id":"` + cursorID + `"}`)},
+		{"missing ID", encode(`{"s":"title","v":null}`)},
+		{"ID not a UUID", encode(`{"s":"title","v":null,"id":"1 OR 1=1"}`)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if cursor, err := listing.DecodeCursor(tt.cursor); err == nil {
+				t.Errorf("decoded %+v", *cursor)
+			}
+		})
+	}
+}
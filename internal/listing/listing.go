@@ -0,0 +1,291 @@
+package listing
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/atavada/project-management-saas/internal/apperror"
+	"github.com/google/uuid"
+)
+
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+
+	// maxSortFields bounds the ORDER BY a client can ask for
+	maxSortFields = 3
+	// maxFilterValues bounds the comma separated values of one filter
+	maxFilterValues = 50
+	// maxSearchLength bounds the q parameter
+	maxSearchLength = 100
+)
+
+// Type is how the values of a filter are parsed
+type Type int
+
+const (
+	// Text values are matched as given
+	Text Type = iota
+	// Enum values must be one of Filter.Values
+	Enum
+	UUID
+	// Date accepts 2006-01-02 or RFC 3339
+	Date
+)
+
+// Op compares a column against the filter value
+type Op string
+
+const (
+	Eq  Op = "="
+	Gte Op = ">="
+	Lte Op = "<="
+)
+
+// Filter declares a query parameter that narrows a list
+type Filter struct {
+	// Column is the SQL expression the value is compared against. It comes from
+	// the Spec, never from the request
+	Column string
+	Op     Op
+	Type   Type
+	// Values lists what an Enum filter accepts
+	Values []string
+	// Cast is the Postgres type Enum values are cast to, so the comparison
+	// can use the column's index
+	Cast string
+	// Multi accepts comma separated values, any of which may match
+	Multi bool
+}
+
+// Spec describes what a list endpoint can be sorted and filtered by. Clients
+// only ever pick names from it, the SQL comes from the Spec itself
+type Spec struct {
+	// Sorts maps sort field names to SQL expressions
+	Sorts       map[string]string
+	DefaultSort []Sort
+	// Tiebreak is a unique column appended to every ORDER BY so pages are stable
+	Tiebreak string
+	Filters  map[string]Filter
+	// Search lists the columns the q parameter is matched against
+	Search []string
+	// Keyset pages through the list with cursors unless the client asks for a
+	// page number. Keyset lists sort by a single field and skip the count
+	Keyset bool
+}
+
+type Sort struct {
+	Field string
+	Desc  bool
+}
+
+// Params is a parsed list request
+type Params struct {
+	Page  int
+	Limit int
+	Sort  []Sort
+	// Filters holds the parsed values by filter name
+	Filters map[string][]any
+	Search  string
+	// Keyset is set when the list is paged with cursors rather than page
+	// numbers. Cursor is nil for the first page
+	Keyset bool
+	Cursor *Cursor
+}
+
+func (p Params) Offset() int {
+	return (p.Page - 1) * p.Limit
+}
+
+// Parse reads page, limit, cursor, sort, q and the filters of spec from the
+// query parameters. Parameters the spec doesn't know are ignored. Invalid
+// values are reported together as a validation error
+func Parse(query map[string]string, spec Spec) (Params, error) {
+	params := Params{
+		Page:    1,
+		Limit:   DefaultLimit,
+		Sort:    spec.DefaultSort,
+		Filters: make(map[string][]any),
+	}
+
+	var fields []apperror.FieldError
+	invalid := func(field, rule, message string) {
+		fields = append(fields, apperror.FieldError{Field: field, Rule: rule, Message: message})
+	}
+
+	_, hasPage := query["page"]
+	params.Keyset = spec.Keyset && !hasPage
+
+	if raw, ok := query["page"]; ok {
+		page, err := strconv.Atoi(raw)
+		if err != nil || page < 1 {
+			invalid("page", "min", "must be a whole number of at least 1")
+		} else {
+			params.Page = page
+		}
+	}
+
+	if raw, ok := query["limit"]; ok {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > MaxLimit {
+			invalid("limit", "max", fmt.Sprintf("must be a whole number between 1 and %d", MaxLimit))
+		} else {
+			params.Limit = limit
+		}
+	}
+
+	if raw := query["sort"]; raw != "" {
+		sort, err := parseSort(raw, spec)
+		if err != nil {
+			invalid("sort", "sort", err.Error())
+		} else {
+			params.Sort = sort
+		}
+	}
+
+	if raw := query["cursor"]; raw != "" {
+		switch cursor, err := DecodeCursor(raw); {
+		case !spec.Keyset:
+			invalid("cursor", "cursor", "is not supported by this list")
+		case hasPage:
+			invalid("cursor", "cursor", "cannot be combined with page")
+		case err != nil:
+			invalid("cursor", "cursor", err.Error())
+		case spec.Sorts[cursor.Sort] == "":
+			invalid("cursor", "cursor", "is not a valid cursor")
+		case query["sort"] == "":
+			// The cursor carries the sort of the page it came from
+			params.Cursor = cursor
+			params.Sort = []Sort{{Field: cursor.Sort, Desc: cursor.Desc}}
+		default:
+			params.Cursor = cursor
+		}
+	}
+
+	if params.Keyset {
+		switch {
+		case len(params.Sort) != 1:
+			invalid("sort", "sort", "must be a single field when paging with cursors")
+		case params.Cursor != nil && params.Cursor.Sort != params.Sort[0].Field,
+			params.Cursor != nil && params.Cursor.Desc != params.Sort[0].Desc:
+			invalid("cursor", "cursor", "was issued for a different sort")
+		}
+	}
+
+	if raw := strings.TrimSpace(query["q"]); raw != "" && len(spec.Search) > 0 {
+		if len([]rune(raw)) > maxSearchLength {
+			invalid("q", "max", fmt.Sprintf("must be at most %d characters", maxSearchLength))
+		} else {
+			params.Search = raw
+		}
+	}
+
+	for name, filter := range spec.Filters {
+		raw, ok := query[name]
+		if !ok || raw == "" {
+			continue
+		}
+		values, err := parseFilter(raw, filter)
+		if err != nil {
+			invalid(name, "filter", err.Error())
+			continue
+		}
+		params.Filters[name] = values
+	}
+
+	if len(fields) > 0 {
+		slices.SortFunc(fields, func(a, b apperror.FieldError) int {
+			return strings.Compare(a.Field, b.Field)
+		})
+		return Params{}, apperror.Validation("Invalid list parameters").WithFields(fields)
+	}
+
+	return params, nil
+}
+
+// parseSort reads a comma separated list of fields, each optionally prefixed
+// with - for descending order, e.g. "-due_date,title"
+func parseSort(raw string, spec Spec) ([]Sort, error) {
+	parts := strings.Split(raw, ",")
+	if len(parts) > maxSortFields {
+		return nil, fmt.Errorf("must have at most %d fields", maxSortFields)
+	}
+
+	sort := make([]Sort, 0, len(parts))
+	seen := make(map[string]bool, len(parts))
+	for _, part := range parts {
+		part = strings.TrimSpace(part)
+		field, desc := strings.CutPrefix(part, "-")
+		if _, ok := spec.Sorts[field]; !ok {
+			return nil, fmt.Errorf("cannot sort by %q, use one of %s", field, strings.Join(sortedKeys(spec.Sorts), ", "))
+		}
+		if seen[field] {
+			return nil, fmt.Errorf("sorts by %q twice", field)
+		}
+		seen[field] = true
+		sort = append(sort, Sort{Field: field, Desc: desc})
+	}
+
+	return sort, nil
+}
+
+func parseFilter(raw string, filter Filter) ([]any, error) {
+	parts := []string{raw}
+	if filter.Multi {
+		parts = strings.Split(raw, ",")
+		if len(parts) > maxFilterValues {
+			return nil, fmt.Errorf("must have at most %d values", maxFilterValues)
+		}
+	}
+
+	values := make([]any, 0, len(parts))
+	for _, part := range parts {
+		part = strings.TrimSpace(part)
+		value, err := parseValue(part, filter)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+
+	return values, nil
+}
+
+func parseValue(raw string, filter Filter) (any, error) {
+	switch filter.Type {
+	case Enum:
+		if !slices.Contains(filter.Values, raw) {
+			return nil, fmt.Errorf("has an unsupported value %q, use one of %s", raw, strings.Join(filter.Values, ", "))
+		}
+		return raw, nil
+	case UUID:
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			return nil, fmt.Errorf("must be a valid UUID")
+		}
+		return id, nil
+	case Date:
+		if t, err := time.Parse(time.DateOnly, raw); err == nil {
+			return t, nil
+		}
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return nil, fmt.Errorf("must be a date, e.g. 2024-01-31")
+		}
+		return t, nil
+	default:
+		return raw, nil
+	}
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
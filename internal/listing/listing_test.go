@@ -0,0 +1,93 @@
+package listing_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/atavada/project-management-saas/internal/apperror"
+	"github.com/atavada/project-management-saas/internal/listing"
+)
+
+var keysetSpec = listing.Spec{
+	Sorts: map[string]string{
+		"created_at": "t.created_at",
+		"due_date":   "t.due_date",
+		"title":      "t.title",
+	},
+	DefaultSort: []listing.Sort{{Field: "created_at", Desc: true}},
+	Tiebreak:    "t.id",
+	Keyset:      true,
+}
+
+func TestParseCursor(t *testing.T) {
+	due := "2026-03-02"
+	cursor := listing.Cursor{Sort: "due_date", Desc: true, Value: &due, ID: cursorID}
+	before := listing.Cursor{Sort: "due_date", ID: cursorID, Before: true}
+
+	tests := []struct {
+		name   string
+		query  map[string]string
+		sort   []listing.Sort
+		cursor *listing.Cursor
+	}{
+		{"first page", map[string]string{}, []listing.Sort{{Field: "created_at", Desc: true}}, nil},
+		{"first page sorted", map[string]string{"sort": "title"}, []listing.Sort{{Field: "title"}}, nil},
+		{"cursor carries its sort", map[string]string{"cursor": cursor.Encode()}, []listing.Sort{{Field: "due_date", Desc: true}}, &cursor},
+		{"cursor with its sort", map[string]string{"cursor": cursor.Encode(), "sort": "-due_date"}, []listing.Sort{{Field: "due_date", Desc: true}}, &cursor},
+		{"before a NULL value", map[string]string{"cursor": before.Encode()}, []listing.Sort{{Field: "due_date"}}, &before},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params, err := listing.Parse(tt.query, keysetSpec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !params.Keyset {
+				t.Error("not paged with cursors")
+			}
+			if !reflect.DeepEqual(params.Sort, tt.sort) {
+				t.Errorf("got sort %+v, want %+v", params.Sort, tt.sort)
+			}
+			if !reflect.DeepEqual(params.Cursor, tt.cursor) {
+				t.Errorf("got cursor %+v, want %+v", params.Cursor, tt.cursor)
+			}
+		})
+	}
+}
+
+func TestParseRejectsCursor(t *testing.T) {
+	due := "2026-03-02"
+	cursor := listing.Cursor{Sort: "due_date", Value: &due, ID: cursorID}.Encode()
+
+	offsetSpec := keysetSpec
+	offsetSpec.Keyset = false
+
+	tests := []struct {
+		name  string
+		query map[string]string
+		spec  listing.Spec
+		field string
+	}{
+		{"tampered", map[string]string{"cursor": cursor[:len(cursor)-2] + "x"}, keysetSpec, "cursor"},
+		{"unknown sort", map[string]string{"cursor": listing.Cursor{Sort: "t.id", ID: cursorID}.Encode()}, keysetSpec, "cursor"},
+		{"different field", map[string]string{"cursor": cursor, "sort": "title"}, keysetSpec, "cursor"},
+		{"different direction", map[string]string{"cursor": cursor, "sort": "-due_date"}, keysetSpec, "cursor"},
+		{"with a page", map[string]string{"cursor": cursor, "page": "2"}, keysetSpec, "cursor"},
+		{"list without cursors", map[string]string{"cursor": cursor}, offsetSpec, "cursor"},
+		{"several sort fields", map[string]string{"sort": "due_date,title"}, keysetSpec, "sort"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := listing.Parse(tt.query, tt.spec)
+			appErr, ok := apperror.As(err)
+			if !ok {
+				t.Fatalf("got %v, want a validation error", err)
+			}
+			if len(appErr.Fields) != 1 || appErr.Fields[0].Field != tt.field {
+				t.Errorf("got fields %+v, want %s", appErr.Fields, tt.field)
+			}
+		})
+	}
+}
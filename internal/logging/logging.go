@@ -0,0 +1,100 @@
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// redacted replaces the value of attributes that hold personal data
+const redacted = "[REDACTED]"
+
+// piiKeys are attribute keys whose values are never written out, at any depth
+var piiKeys = map[string]bool{
+	"email":         true,
+	"first_name":    true,
+	"last_name":     true,
+	"name":          true,
+	"display_name":  true,
+	"avatar_url":    true,
+	"image_url":     true,
+	"phone":         true,
+	"ip":            true,
+	"authorization": true,
+	"token":         true,
+}
+
+// New returns a JSON logger that adds the attributes stored in the context
+// with With, and redacts personal data
+func New(w io.Writer, level slog.Level) *slog.Logger {
+	handler := slog.NewJSONHandler(w, &slog.HandlerOptions{
+		Level:       level,
+		ReplaceAttr: redact,
+	})
+	return slog.New(contextHandler{handler})
+}
+
+func redact(groups []string, a slog.Attr) slog.Attr {
+	if piiKeys[strings.ToLower(a.Key)] {
+		return slog.String(a.Key, redacted)
+	}
+	return a
+}
+
+type ctxKey int
+
+const (
+	attrsKey ctxKey = iota
+	requestIDKey
+)
+
+// With returns a context whose log lines carry the given attributes, in
+// addition to those already attached to ctx
+func With(ctx context.Context, args ...any) context.Context {
+	parent, _ := ctx.Value(attrsKey).([]slog.Attr)
+
+	record := slog.Record{}
+	record.Add(args...)
+
+	attrs := make([]slog.Attr, 0, len(parent)+record.NumAttrs())
+	attrs = append(attrs, parent...)
+	record.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, a)
+		return true
+	})
+
+	return context.WithValue(ctx, attrsKey, attrs)
+}
+
+// WithRequestID attaches the request ID to ctx and to its log lines
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	ctx = context.WithValue(ctx, requestIDKey, requestID)
+	return With(ctx, "request_id", requestID)
+}
+
+// RequestID returns the ID of the request ctx belongs to, or ""
+func RequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey).(string)
+	return requestID
+}
+
+// contextHandler adds the attributes stored in the context to each record
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if attrs, ok := ctx.Value(attrsKey).([]slog.Attr); ok {
+		r.AddAttrs(attrs...)
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,79 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+const namespace = "align"
+
+// Registry holds every metric exposed on /metrics. A dedicated registry keeps
+// collectors registered by dependencies out of the output
+var Registry = prometheus.NewRegistry()
+
+var (
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests handled, by method, route and status.",
+	}, []string{"method", "route", "status"})
+
+	HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency, by method, route and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	WebhookEventsProcessed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "webhook_events_processed_total",
+		Help:      "Clerk webhook events applied successfully, by event type.",
+	}, []string{"type"})
+
+	WebhookEventsFailed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "webhook_events_failed_total",
+		Help:      "Clerk webhook events that could not be applied, by event type.",
+	}, []string{"type"})
+
+	WebhookEventsIgnored = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "webhook_events_ignored_total",
+		Help:      "Clerk webhook events with a type the API does not handle.",
+	}, []string{"type"})
+
+	Jobs = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "jobs_total",
+		Help:      "Background jobs finished, by job and outcome.",
+	}, []string{"job", "outcome"})
+
+	JobDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "job_duration_seconds",
+		Help:      "Background job run time, by job and outcome.",
+		Buckets:   []float64{0.1, 0.5, 1, 5, 15, 30, 60, 120, 300},
+	}, []string{"job", "outcome"})
+)
+
+// Job outcomes
+const (
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed"
+	JobCancelled = "cancelled"
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequests,
+		HTTPRequestDuration,
+		WebhookEventsProcessed,
+		WebhookEventsFailed,
+		WebhookEventsIgnored,
+		Jobs,
+		JobDuration,
+	)
+}
@@ -0,0 +1,77 @@
+package metrics
+
+import (
+	"github.com/atavada/project-management-saas/internal/database"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// poolCollector reads pgxpool statistics at scrape time, labelled with the pool
+// they belong to (primary or replica)
+type poolCollector struct {
+	db *database.DB
+
+	totalConns    *prometheus.Desc
+	idleConns     *prometheus.Desc
+	acquiredConns *prometheus.Desc
+	maxConns      *prometheus.Desc
+	acquires      *prometheus.Desc
+	acquireWait   *prometheus.Desc
+	emptyAcquires *prometheus.Desc
+	cancelled     *prometheus.Desc
+}
+
+// RegisterPool exposes the connection pool statistics of db
+func RegisterPool(db *database.DB) error {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "db_pool", name),
+			help,
+			[]string{"pool"},
+			nil,
+		)
+	}
+
+	return Registry.Register(&poolCollector{
+		db:            db,
+		totalConns:    desc("total_conns", "Connections currently open."),
+		idleConns:     desc("idle_conns", "Open connections not in use."),
+		acquiredConns: desc("acquired_conns", "Connections currently checked out."),
+		maxConns:      desc("max_conns", "Maximum size of the pool."),
+		acquires:      desc("acquires_total", "Connections acquired from the pool."),
+		acquireWait:   desc("acquire_wait_seconds_total", "Time spent waiting for a connection."),
+		emptyAcquires: desc("empty_acquires_total", "Acquires that had to wait because the pool was empty."),
+		cancelled:     desc("canceled_acquires_total", "Acquires cancelled by their context while waiting."),
+	})
+}
+
+func (p *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- p.totalConns
+	ch <- p.idleConns
+	ch <- p.acquiredConns
+	ch <- p.maxConns
+	ch <- p.acquires
+	ch <- p.acquireWait
+	ch <- p.emptyAcquires
+	ch <- p.cancelled
+}
+
+func (p *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	p.collect(ch, "primary", p.db.Pool)
+	if p.db.Replica != nil {
+		p.collect(ch, "replica", p.db.Replica)
+	}
+}
+
+func (p *poolCollector) collect(ch chan<- prometheus.Metric, name string, pool *pgxpool.Pool) {
+	stat := pool.Stat()
+
+	ch <- prometheus.MustNewConstMetric(p.totalConns, prometheus.GaugeValue, float64(stat.TotalConns()), name)
+	ch <- prometheus.MustNewConstMetric(p.idleConns, prometheus.GaugeValue, float64(stat.IdleConns()), name)
+	ch <- prometheus.MustNewConstMetric(p.acquiredConns, prometheus.GaugeValue, float64(stat.AcquiredConns()), name)
+	ch <- prometheus.MustNewConstMetric(p.maxConns, prometheus.GaugeValue, float64(stat.MaxConns()), name)
+	ch <- prometheus.MustNewConstMetric(p.acquires, prometheus.CounterValue, float64(stat.AcquireCount()), name)
+	ch <- prometheus.MustNewConstMetric(p.acquireWait, prometheus.CounterValue, stat.AcquireDuration().Seconds(), name)
+	ch <- prometheus.MustNewConstMetric(p.emptyAcquires, prometheus.CounterValue, float64(stat.EmptyAcquireCount()), name)
+	ch <- prometheus.MustNewConstMetric(p.cancelled, prometheus.CounterValue, float64(stat.CanceledAcquireCount()), name)
+}
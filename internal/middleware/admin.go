@@ -0,0 +1,20 @@
+package middleware
+
+import (
+	"slices"
+
+	"github.com/atavada/project-management-saas/internal/apperror"
+	"github.com/gofiber/fiber/v3"
+)
+
+// RequireAdmin lets only the platform admins listed by Clerk user ID through.
+// It runs after AuthMiddleware, which sets the user
+func RequireAdmin(adminUserIDs []string) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		clerkUserID, _ := c.Locals("clerkUserID").(string)
+		if clerkUserID == "" || !slices.Contains(adminUserIDs, clerkUserID) {
+			return apperror.Forbidden("Access denied")
+		}
+		return c.Next()
+	}
+}
@@ -6,46 +6,90 @@ import (
 
 	clerk "github.com/clerk/clerk-sdk-go/v2"
 	"github.com/clerk/clerk-sdk-go/v2/jwt"
+	"github.com/atavada/project-management-saas/internal/apperror"
+	"github.com/atavada/project-management-saas/internal/audit"
+	"github.com/atavada/project-management-saas/internal/logging"
 	"github.com/gofiber/fiber/v3"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
 )
 
-func AuthMiddleware(clerkSecretKey string) fiber.Handler {
+// Claims is what the API needs from a verified session token
+type Claims struct {
+	UserID string
+	// OrgID is the active organization, empty when none is selected
+	OrgID string
+}
+
+// TokenVerifier checks a bearer token and returns who it was issued to
+type TokenVerifier interface {
+	Verify(ctx context.Context, token string) (*Claims, error)
+}
+
+// ClerkVerifier verifies Clerk session tokens against the instance JWKS
+type ClerkVerifier struct {
+	secretKey string
+}
+
+func NewClerkVerifier(secretKey string) *ClerkVerifier {
+	return &ClerkVerifier{secretKey: secretKey}
+}
+
+func (v *ClerkVerifier) Verify(ctx context.Context, token string) (*Claims, error) {
+	clerk.SetKey(v.secretKey)
+	claims, err := jwt.Verify(ctx, &jwt.VerifyParams{
+		Token: token,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Claims{UserID: claims.Subject, OrgID: claims.ActiveOrganizationID}, nil
+}
+
+func AuthMiddleware(verifier TokenVerifier) fiber.Handler {
 	return func(c fiber.Ctx) error {
 		// Get token from Authorization header
 		authHeader := c.Get("Authorization")
 		if authHeader == "" {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error": "Missing authorization header",
-			})
+			return apperror.Unauthorized("Missing authorization header")
 		}
 
 		// Extract token
 		tokenParts := strings.Split(authHeader, " ")
 		if len(tokenParts) != 2 || tokenParts[0] != "Bearer" {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error": "Invalid authorization header format",
-			})
+			return apperror.Unauthorized("Invalid authorization header format")
 		}
 
 		token := tokenParts[1]
 
-		// Verify token with Clerk
-		clerk.SetKey(clerkSecretKey)
-		claims, err := jwt.Verify(context.Background(), &jwt.VerifyParams{
-			Token: token,
-		})
+		// Verify the token, with Clerk fetching the JWKS is the slow part when
+		// the key isn't cached yet
+		ctx, span := tracer.Start(c.Context(), "clerk.verify_token")
+		claims, err := verifier.Verify(ctx, token)
 		if err != nil {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error": "Invalid token",
-			})
+			span.SetStatus(codes.Error, "invalid token")
+			span.End()
+			return apperror.Unauthorized("Invalid token")
 		}
 
+		span.SetAttributes(attribute.String("clerk.user_id", claims.UserID))
+		span.End()
+
 		// Store user info in context
-		c.Locals("clerkUserID", claims.Subject)
-		if claims.ActiveOrganizationID != "" {
-			c.Locals("clerkOrgID", claims.ActiveOrganizationID)
+		c.Locals("clerkUserID", claims.UserID)
+		if claims.OrgID != "" {
+			c.Locals("clerkOrgID", claims.OrgID)
+		}
+
+		// Tag every log line of the request with who made it
+		logCtx := logging.With(c.Context(), "user_id", claims.UserID)
+		if claims.OrgID != "" {
+			logCtx = logging.With(logCtx, "org_id", claims.OrgID)
 		}
+		// Changes made by the request are audited as this user
+		c.SetContext(audit.WithActor(logCtx, audit.User(claims.UserID)))
 
 		return c.Next()
 	}
-}
\ No newline at end of file
+}
@@ -0,0 +1,15 @@
+package middleware
+
+import (
+	"github.com/atavada/project-management-saas/internal/audit"
+	"github.com/gofiber/fiber/v3"
+)
+
+// ClientIP attaches the caller's address to the request context, audit events
+// record it
+func ClientIP() fiber.Handler {
+	return func(c fiber.Ctx) error {
+		c.SetContext(audit.WithClientIP(c.Context(), c.IP()))
+		return c.Next()
+	}
+}
@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/atavada/project-management-saas/internal/apperror"
+	"github.com/atavada/project-management-saas/internal/logging"
+	"github.com/atavada/project-management-saas/internal/models"
+	"github.com/gofiber/fiber/v3"
+)
+
+// MIMEApplicationProblemJSON is the media type of RFC 7807 error bodies
+const MIMEApplicationProblemJSON = "application/problem+json"
+
+// ErrorHandler answers every error returned by handlers and middleware. Clients
+// get the status, stable code and message of an *apperror.Error; anything else
+// is logged and answered with a generic 500, so internal details never leak.
+// Clients that prefer application/problem+json get an RFC 7807 body
+func ErrorHandler(c fiber.Ctx, err error) error {
+	appErr := toAppError(err)
+	status := appErr.Status()
+
+	if status >= fiber.StatusInternalServerError {
+		slog.ErrorContext(c.Context(), "Request failed", "error", err, "code", appErr.Code)
+	}
+
+	requestID := logging.RequestID(c.Context())
+
+	if c.Accepts(fiber.MIMEApplicationJSON, MIMEApplicationProblemJSON) == MIMEApplicationProblemJSON {
+		return c.Status(status).JSON(models.ProblemDetails{
+			Type:      "about:blank",
+			Title:     http.StatusText(status),
+			Status:    status,
+			Detail:    appErr.Message,
+			Instance:  c.Path(),
+			Code:      string(appErr.Code),
+			Errors:    appErr.Fields,
+			RequestID: requestID,
+		}, MIMEApplicationProblemJSON)
+	}
+
+	return c.Status(status).JSON(models.ErrorResponse{
+		Error:     appErr.Message,
+		Code:      string(appErr.Code),
+		Fields:    appErr.Fields,
+		RequestID: requestID,
+	})
+}
+
+// toAppError returns the client facing form of err
+func toAppError(err error) *apperror.Error {
+	if appErr, ok := apperror.As(err); ok {
+		return appErr
+	}
+
+	// Raised by Fiber itself, e.g. no route matched. Their messages are safe
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		return apperror.FromStatus(fiberErr.Code, fiberErr.Message)
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return apperror.Timeout("Request timed out")
+	}
+
+	return apperror.Internal("Internal Server Error", err)
+}
@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// Logger writes one access log line per request. It reads the context after
+// the chain returns, so the line carries the user and organization IDs attached
+// by AuthMiddleware. The query string is left out, it can hold personal data
+func Logger() fiber.Handler {
+	return func(c fiber.Ctx) error {
+		start := time.Now()
+		self := c.Route()
+
+		err := c.Next()
+
+		status := responseStatus(c, err)
+		level := slog.LevelInfo
+		switch {
+		case status >= fiber.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= fiber.StatusBadRequest:
+			level = slog.LevelWarn
+		}
+
+		slog.LogAttrs(c.Context(), level, "request",
+			slog.String("method", c.Method()),
+			slog.String("path", c.Path()),
+			slog.String("route", routePattern(c, self)),
+			slog.Int("status", status),
+			slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+		)
+
+		return err
+	}
+}
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/atavada/project-management-saas/internal/metrics"
+	"github.com/gofiber/fiber/v3"
+)
+
+// Metrics records the count and latency of every request. Requests are
+// labelled with the route pattern rather than the raw path, so IDs and slugs
+// don't multiply the series; requests that match no route share "unmatched"
+func Metrics() fiber.Handler {
+	return func(c fiber.Ctx) error {
+		start := time.Now()
+		self := c.Route()
+
+		err := c.Next()
+
+		route := routePattern(c, self)
+		status := responseStatus(c, err)
+
+		labels := []string{c.Method(), route, strconv.Itoa(status)}
+		metrics.HTTPRequests.WithLabelValues(labels...).Inc()
+		metrics.HTTPRequestDuration.WithLabelValues(labels...).Observe(time.Since(start).Seconds())
+
+		return err
+	}
+}
+
+// routePattern returns the pattern of the route that handled the request, or
+// "unmatched". self is the route of the calling app level middleware: when no
+// route matched, the last handler to run is a middleware on the same path
+func routePattern(c fiber.Ctx, self *fiber.Route) string {
+	if route := c.Route().Path; route != self.Path {
+		return route
+	}
+	return "unmatched"
+}
+
+// responseStatus returns the status the request will be answered with. The
+// error handler only writes the response after the chain returns, so the
+// status comes from the error when there is one
+func responseStatus(c fiber.Ctx, err error) int {
+	if err == nil {
+		return c.Response().StatusCode()
+	}
+	return toAppError(err).Status()
+}
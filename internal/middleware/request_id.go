@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"regexp"
+
+	"github.com/atavada/project-management-saas/internal/logging"
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+)
+
+// HeaderRequestID carries the request ID in both directions
+const HeaderRequestID = "X-Request-ID"
+
+// requestIDPattern bounds IDs taken from callers, they end up in every log line
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// RequestID takes the request ID from the X-Request-ID header, or generates one,
+// echoes it in the response and attaches it to the request context so every
+// log line and error body written for the request includes it
+func RequestID() fiber.Handler {
+	return func(c fiber.Ctx) error {
+		requestID := c.Get(HeaderRequestID)
+		if !requestIDPattern.MatchString(requestID) {
+			requestID = uuid.NewString()
+		}
+
+		c.Set(HeaderRequestID, requestID)
+		c.SetContext(logging.WithRequestID(c.Context(), requestID))
+
+		return c.Next()
+	}
+}
@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/atavada/project-management-saas/internal/apperror"
+	"github.com/gofiber/fiber/v3"
+)
+
+// Timeout gives the request a context with a deadline, available to handlers
+// through c.Context(), so repository queries are cancelled once it passes. A
+// handler that fails because it ran out of time is answered with 504 Gateway
+// Timeout; a response the handler already produced is left alone.
+//
+// Cancelling work when the client disconnects is out of scope: fasthttp, which
+// Fiber runs on, does not report closed connections while a handler runs, and
+// reading from the connection ourselves would race the server. The deadline is
+// what bounds work for clients that went away.
+func Timeout(timeout time.Duration) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(c.Context(), timeout)
+		defer cancel()
+
+		c.SetContext(ctx)
+		err := c.Next()
+
+		if errors.Is(err, context.DeadlineExceeded) && ctx.Err() != nil {
+			return apperror.Timeout("Request timed out").Wrap(err)
+		}
+
+		return err
+	}
+}
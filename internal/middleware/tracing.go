@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"github.com/atavada/project-management-saas/internal/logging"
+	"github.com/gofiber/fiber/v3"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/atavada/project-management-saas/internal/middleware")
+
+// Tracing starts a server span for every request, continuing the trace of the
+// caller when it sent a traceparent header. The span is stored in the request
+// context, so repository queries made by handlers become its children
+func Tracing() fiber.Handler {
+	return func(c fiber.Ctx) error {
+		self := c.Route()
+
+		ctx := otel.GetTextMapPropagator().Extract(c.Context(), requestHeaderCarrier{c})
+		ctx, span := tracer.Start(ctx, c.Method(),
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(c.Method()),
+				semconv.URLPath(c.Path()),
+				semconv.UserAgentOriginal(c.Get(fiber.HeaderUserAgent)),
+			),
+		)
+		defer span.End()
+
+		// Log lines of the request can then be found from the trace and back
+		if sc := span.SpanContext(); sc.IsValid() {
+			ctx = logging.With(ctx, "trace_id", sc.TraceID().String(), "span_id", sc.SpanID().String())
+		}
+
+		c.SetContext(ctx)
+		err := c.Next()
+
+		route := routePattern(c, self)
+		status := responseStatus(c, err)
+
+		span.SetName(c.Method() + " " + route)
+		span.SetAttributes(semconv.HTTPRoute(route), semconv.HTTPResponseStatusCode(status))
+		if status >= fiber.StatusInternalServerError {
+			span.SetStatus(codes.Error, fiber.ErrInternalServerError.Message)
+			if err != nil {
+				span.RecordError(err)
+			}
+		}
+
+		return err
+	}
+}
+
+// requestHeaderCarrier reads propagation headers from the incoming request
+type requestHeaderCarrier struct {
+	c fiber.Ctx
+}
+
+var _ propagation.TextMapCarrier = requestHeaderCarrier{}
+
+func (h requestHeaderCarrier) Get(key string) string {
+	return h.c.Get(key)
+}
+
+func (h requestHeaderCarrier) Set(key, value string) {
+	h.c.Request().Header.Set(key, value)
+}
+
+func (h requestHeaderCarrier) Keys() []string {
+	keys := make([]string, 0)
+	for key := range h.c.GetReqHeaders() {
+		keys = append(keys, key)
+	}
+	return keys
+}
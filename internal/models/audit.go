@@ -0,0 +1,88 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AuditActorType is who made a change: a signed in user, an operator tool or
+// a webhook from an external system
+type AuditActorType string
+
+const (
+	AuditActorUser           AuditActorType = "user"
+	AuditActorServiceAccount AuditActorType = "service_account"
+	AuditActorWebhook        AuditActorType = "webhook"
+)
+
+func (t AuditActorType) IsValid() bool {
+	switch t {
+	case AuditActorUser, AuditActorServiceAccount, AuditActorWebhook:
+		return true
+	}
+	return false
+}
+
+type AuditAction string
+
+const (
+	AuditCreated AuditAction = "created"
+	AuditUpdated AuditAction = "updated"
+	AuditDeleted AuditAction = "deleted"
+)
+
+func (a AuditAction) IsValid() bool {
+	switch a {
+	case AuditCreated, AuditUpdated, AuditDeleted:
+		return true
+	}
+	return false
+}
+
+// AuditEntityType names the kind of record an audit event is about
+type AuditEntityType string
+
+const (
+	AuditEntityUser               AuditEntityType = "user"
+	AuditEntityUserPreferences    AuditEntityType = "user_preferences"
+	AuditEntityOrganization       AuditEntityType = "organization"
+	AuditEntityOrganizationMember AuditEntityType = "organization_member"
+	AuditEntityProject            AuditEntityType = "project"
+	AuditEntityTask               AuditEntityType = "task"
+	AuditEntityTaskComment        AuditEntityType = "task_comment"
+	AuditEntityDataExport         AuditEntityType = "data_export"
+)
+
+func (t AuditEntityType) IsValid() bool {
+	switch t {
+	case AuditEntityUser, AuditEntityUserPreferences, AuditEntityOrganization, AuditEntityOrganizationMember,
+		AuditEntityProject, AuditEntityTask, AuditEntityTaskComment, AuditEntityDataExport:
+		return true
+	}
+	return false
+}
+
+// AuditChange is the value of one field before and after a change. Before is
+// nil for created records and After for deleted ones
+type AuditChange struct {
+	Before any `json:"before"`
+	After  any `json:"after"`
+}
+
+// AuditEvent records one change to one record. ActorID is the user behind a
+// user actor, ActorName names service accounts and webhook sources
+type AuditEvent struct {
+	ID             uuid.UUID              `json:"id"`
+	OrganizationID *uuid.UUID             `json:"organization_id"`
+	ActorType      AuditActorType         `json:"actor_type"`
+	ActorID        *uuid.UUID             `json:"actor_id"`
+	ActorName      string                 `json:"actor_name"`
+	EntityType     AuditEntityType        `json:"entity_type"`
+	EntityID       uuid.UUID              `json:"entity_id"`
+	Action         AuditAction            `json:"action"`
+	Changes        map[string]AuditChange `json:"changes"`
+	IPAddress      *string                `json:"ip_address"`
+	RequestID      *string                `json:"request_id"`
+	CreatedAt      time.Time              `json:"created_at"`
+}
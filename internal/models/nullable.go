@@ -0,0 +1,26 @@
+package models
+
+import "encoding/json"
+
+// Nullable is a field of a partial update that can be cleared. Set tells a
+// field sent as null, which clears the value, from one left out, which keeps it
+type Nullable[T any] struct {
+	Set   bool
+	Value *T
+}
+
+func (n *Nullable[T]) UnmarshalJSON(data []byte) error {
+	n.Set = true
+	if string(data) == "null" {
+		n.Value = nil
+		return nil
+	}
+
+	var value T
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	n.Value = &value
+
+	return nil
+}
@@ -13,6 +13,7 @@ type Organization struct {
     Slug        string     `json:"slug"`
     Description string     `json:"description"`
     LogoURL     string     `json:"logo_url"`
+    Settings    OrganizationSettings `json:"settings"`
     CreatedAt   time.Time  `json:"created_at"`
     UpdatedAt   time.Time  `json:"updated_at"`
 }
@@ -25,11 +26,30 @@ const (
     RoleMember OrganizationRole = "member"
 )
 
+func (r OrganizationRole) IsValid() bool {
+	switch r {
+	case RoleOwner, RoleAdmin, RoleMember:
+		return true
+	}
+	return false
+}
+
+// RoleFromClerk maps a Clerk membership role to ours. Clerk roles may carry
+// the "org:" prefix; owners are only assigned locally
+func RoleFromClerk(role string) OrganizationRole {
+	switch role {
+	case "admin", "org:admin":
+		return RoleAdmin
+	default:
+		return RoleMember
+	}
+}
+
 type OrganizationMember struct {
 		ID                uuid.UUID        `json:"id"`
     OrganizationID    uuid.UUID        `json:"organization_id"`
     UserID            uuid.UUID        `json:"user_id"`
-    Role              OrganizationRole `json:"role"`
+    Role              OrganizationRole `json:"role" validate:"enum"`
     ClerkMembershipID string           `json:"clerk_membership_id"`
     JoinedAt          time.Time        `json:"joined_at"`
     UpdatedAt         time.Time        `json:"updated_at"`
@@ -42,8 +62,47 @@ type OrganizationWithRole struct {
 
 type CreateOrganizationRequest struct {
 		ClerkOrgID  string `json:"clerk_org_id" validate:"required"`
-    Name        string `json:"name" validate:"required"`
-    Slug        string `json:"slug" validate:"required"`
+    Name        string `json:"name" validate:"required,max=255"`
+    Slug        string `json:"slug" validate:"required,max=255"`
     Description string `json:"description"`
     LogoURL     string `json:"logo_url"`
-}
\ No newline at end of file
+}
+
+// OrganizationSettings holds app-owned configuration that Clerk knows nothing about
+type OrganizationSettings struct {
+	DefaultTaskPriority TaskPriority `json:"default_task_priority"`
+	WorkingDays         []Weekday    `json:"working_days"`
+	Timezone            string       `json:"timezone"`
+}
+
+type Weekday string
+
+const (
+	Monday    Weekday = "mon"
+	Tuesday   Weekday = "tue"
+	Wednesday Weekday = "wed"
+	Thursday  Weekday = "thu"
+	Friday    Weekday = "fri"
+	Saturday  Weekday = "sat"
+	Sunday    Weekday = "sun"
+)
+
+func (d Weekday) IsValid() bool {
+	switch d {
+	case Monday, Tuesday, Wednesday, Thursday, Friday, Saturday, Sunday:
+		return true
+	}
+	return false
+}
+
+// UpdateOrganizationRequest is a partial update, nil fields are left unchanged
+type UpdateOrganizationRequest struct {
+	Description *string                            `json:"description"`
+	Settings    *UpdateOrganizationSettingsRequest `json:"settings"`
+}
+
+type UpdateOrganizationSettingsRequest struct {
+	DefaultTaskPriority *TaskPriority `json:"default_task_priority" validate:"omitempty,enum"`
+	WorkingDays         []Weekday     `json:"working_days" validate:"omitempty,min=1,unique,dive,enum"`
+	Timezone            *string       `json:"timezone" validate:"omitempty,timezone"`
+}
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ProjectStatus string
+
+const (
+	ProjectStatusActive    ProjectStatus = "active"
+	ProjectStatusArchived  ProjectStatus = "archived"
+	ProjectStatusCompleted ProjectStatus = "completed"
+)
+
+func (s ProjectStatus) IsValid() bool {
+	switch s {
+	case ProjectStatusActive, ProjectStatusArchived, ProjectStatusCompleted:
+		return true
+	}
+	return false
+}
+
+type Project struct {
+	ID             uuid.UUID     `json:"id"`
+	OrganizationID uuid.UUID     `json:"organization_id"`
+	Name           string        `json:"name"`
+	Description    string        `json:"description"`
+	Status         ProjectStatus `json:"status"`
+	StartDate      *time.Time    `json:"start_date"`
+	EndDate        *time.Time    `json:"end_date"`
+	CreatedAt      time.Time     `json:"created_at"`
+	UpdatedAt      time.Time     `json:"updated_at"`
+}
+
+type CreateProjectRequest struct {
+	OrganizationID uuid.UUID     `json:"organization_id" validate:"required"`
+	Name           string        `json:"name" validate:"required,max=255"`
+	Description    string        `json:"description"`
+	Status         ProjectStatus `json:"status" validate:"omitempty,enum"`
+	StartDate      *time.Time    `json:"start_date"`
+	EndDate        *time.Time    `json:"end_date"`
+}
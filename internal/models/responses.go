@@ -1,23 +1,77 @@
 package models
 
+import "github.com/atavada/project-management-saas/internal/apperror"
+
 type ErrorResponse struct {
-		Error   string `json:"error"`
-    Message string `json:"message,omitempty"`
+	Error     string                `json:"error"`
+	Code      string                `json:"code"`
+	Fields    []apperror.FieldError `json:"fields,omitempty"`
+	RequestID string                `json:"request_id,omitempty"`
+}
+
+// ProblemDetails is the RFC 7807 form of ErrorResponse, sent to clients that
+// accept application/problem+json
+type ProblemDetails struct {
+	Type      string                `json:"type"`
+	Title     string                `json:"title"`
+	Status    int                   `json:"status"`
+	Detail    string                `json:"detail"`
+	Instance  string                `json:"instance,omitempty"`
+	Code      string                `json:"code"`
+	Errors    []apperror.FieldError `json:"errors,omitempty"`
+	RequestID string                `json:"request_id,omitempty"`
 }
 
 type SuccessResponse struct {
-		Message string      `json:"message"`
-    Data    interface{} `json:"data,omitempty"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
 }
 
 type PaginationMeta struct {
-    Page       int   `json:"page"`
-    Limit      int   `json:"limit"`
-    TotalItems int64 `json:"total_items"`
-    TotalPages int   `json:"total_pages"`
+	Page       int   `json:"page"`
+	Limit      int   `json:"limit"`
+	TotalItems int64 `json:"total_items"`
+	TotalPages int   `json:"total_pages"`
 }
 
 type PaginatedResponse struct {
-    Data       interface{}    `json:"data"`
-    Pagination PaginationMeta `json:"pagination"`
-}
\ No newline at end of file
+	Data       interface{}    `json:"data"`
+	Pagination PaginationMeta `json:"pagination"`
+}
+
+// CursorMeta links a keyset paginated page to its neighbours. A cursor is
+// null at the end of the list it points to
+type CursorMeta struct {
+	Limit      int     `json:"limit"`
+	NextCursor *string `json:"next_cursor"`
+	PrevCursor *string `json:"prev_cursor"`
+}
+
+type CursorPaginatedResponse struct {
+	Data       interface{} `json:"data"`
+	Pagination CursorMeta  `json:"pagination"`
+}
+
+func NewPaginationMeta(page, limit int, totalItems int64) PaginationMeta {
+	totalPages := 0
+	if limit > 0 {
+		totalPages = int((totalItems + int64(limit) - 1) / int64(limit))
+	}
+	return PaginationMeta{
+		Page:       page,
+		Limit:      limit,
+		TotalItems: totalItems,
+		TotalPages: totalPages,
+	}
+}
+
+func NewCursorMeta(limit int, next, prev string) CursorMeta {
+	meta := CursorMeta{Limit: limit}
+	if next != "" {
+		meta.NextCursor = &next
+	}
+	if prev != "" {
+		meta.PrevCursor = &prev
+	}
+	return meta
+}
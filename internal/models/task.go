@@ -0,0 +1,82 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type TaskStatus string
+
+const (
+	TaskStatusTodo       TaskStatus = "todo"
+	TaskStatusInProgress TaskStatus = "in_progress"
+	TaskStatusDone       TaskStatus = "done"
+)
+
+func (s TaskStatus) IsValid() bool {
+	switch s {
+	case TaskStatusTodo, TaskStatusInProgress, TaskStatusDone:
+		return true
+	}
+	return false
+}
+
+type TaskPriority string
+
+const (
+	PriorityLow    TaskPriority = "low"
+	PriorityMedium TaskPriority = "medium"
+	PriorityHigh   TaskPriority = "high"
+)
+
+func (p TaskPriority) IsValid() bool {
+	switch p {
+	case PriorityLow, PriorityMedium, PriorityHigh:
+		return true
+	}
+	return false
+}
+
+type Task struct {
+	ID           uuid.UUID    `json:"id"`
+	ProjectID    uuid.UUID    `json:"project_id"`
+	AssignedTo   *uuid.UUID   `json:"assigned_to"`
+	CreatedBy    uuid.UUID    `json:"created_by"`
+	Title        string       `json:"title"`
+	Description  string       `json:"description"`
+	Status       TaskStatus   `json:"status"`
+	Priority     TaskPriority `json:"priority"`
+	DueDate      *time.Time   `json:"due_date"`
+	ReminderSent bool         `json:"reminder_sent"`
+	CompletedAt  *time.Time   `json:"completed_at"`
+	CreatedAt    time.Time    `json:"created_at"`
+	UpdatedAt    time.Time    `json:"updated_at"`
+}
+
+type CreateTaskRequest struct {
+	ProjectID   uuid.UUID    `json:"project_id" validate:"required"`
+	AssignedTo  *uuid.UUID   `json:"assigned_to"`
+	CreatedBy   uuid.UUID    `json:"created_by" validate:"required"`
+	Title       string       `json:"title" validate:"required,max=500"`
+	Description string       `json:"description"`
+	Status      TaskStatus   `json:"status" validate:"omitempty,enum"`
+	Priority    TaskPriority `json:"priority" validate:"omitempty,enum"`
+	DueDate     *time.Time   `json:"due_date"`
+}
+
+// UpdateTaskRequest is a partial update, fields left out are unchanged.
+// AssignedTo and DueDate can be sent as null to unassign the task or clear
+// its due date
+type UpdateTaskRequest struct {
+	Title       *string             `json:"title" validate:"omitempty,min=1,max=500"`
+	Description *string             `json:"description"`
+	Status      *TaskStatus         `json:"status" validate:"omitempty,enum"`
+	Priority    *TaskPriority       `json:"priority" validate:"omitempty,enum"`
+	AssignedTo  Nullable[uuid.UUID] `json:"assigned_to"`
+	DueDate     Nullable[time.Time] `json:"due_date"`
+}
+
+type CreateTaskCommentRequest struct {
+	Body string `json:"body" validate:"required,max=10000"`
+}
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TaskActivityType is the kind of entry in a task's history
+type TaskActivityType string
+
+const (
+	TaskActivityStatusChanged   TaskActivityType = "status_changed"
+	TaskActivityReassigned      TaskActivityType = "reassigned"
+	TaskActivityPriorityChanged TaskActivityType = "priority_changed"
+	TaskActivityDueDateChanged  TaskActivityType = "due_date_changed"
+	TaskActivityCommented       TaskActivityType = "commented"
+)
+
+func (t TaskActivityType) IsValid() bool {
+	switch t {
+	case TaskActivityStatusChanged, TaskActivityReassigned, TaskActivityPriorityChanged, TaskActivityDueDateChanged,
+		TaskActivityCommented:
+		return true
+	}
+	return false
+}
+
+// TaskActivity is one entry in a task's history. From and To hold the old and
+// new status, priority, assignee ID or due date (2006-01-02), nil when there
+// was none; Body holds the text of comments
+type TaskActivity struct {
+	ID        uuid.UUID          `json:"id"`
+	TaskID    uuid.UUID          `json:"task_id"`
+	ActorID   *uuid.UUID         `json:"actor_id"`
+	Actor     *TaskActivityActor `json:"actor"`
+	Type      TaskActivityType   `json:"type"`
+	From      *string            `json:"from"`
+	To        *string            `json:"to"`
+	Body      *string            `json:"body"`
+	CreatedAt time.Time          `json:"created_at"`
+}
+
+// TaskActivityActor is the user behind an activity entry, enough to render
+// "Alice moved this to In Progress". It is nil once the user is deleted
+type TaskActivityActor struct {
+	ID        uuid.UUID `json:"id"`
+	FirstName string    `json:"first_name"`
+	LastName  string    `json:"last_name"`
+	AvatarURL string    `json:"avatar_url"`
+}
@@ -19,8 +19,63 @@ type User struct {
 
 type CreateUserRequest struct {
 		ClerkUserID string `json:"clerk_user_id" validate:"required"`
-    Email       string `json:"email" validate:"required,email"`
-    FirstName   string `json:"first_name"`
-    LastName    string `json:"last_name"`
+    Email       string `json:"email" validate:"required,email,max=255"`
+    FirstName   string `json:"first_name" validate:"max=100"`
+    LastName    string `json:"last_name" validate:"max=100"`
     AvatarURL   string `json:"avatar_url"`
-}
\ No newline at end of file
+}
+
+// UserPreferences holds app-owned profile fields. Name, email and avatar are
+// owned by Clerk and live on User
+type UserPreferences struct {
+	UserID        uuid.UUID               `json:"user_id"`
+	Timezone      string                  `json:"timezone"`
+	Locale        string                  `json:"locale"`
+	DisplayName   *string                 `json:"display_name"`
+	Notifications NotificationPreferences `json:"notifications"`
+	UpdatedAt     time.Time               `json:"updated_at"`
+}
+
+type NotificationPreferences struct {
+	TaskAssigned  bool `json:"task_assigned"`
+	TaskDueSoon   bool `json:"task_due_soon"`
+	TaskCommented bool `json:"task_commented"`
+	WeeklyDigest  bool `json:"weekly_digest"`
+}
+
+// DefaultUserPreferences mirrors the column defaults of user_preferences for
+// users that never saved any
+func DefaultUserPreferences(userID uuid.UUID) *UserPreferences {
+	return &UserPreferences{
+		UserID:   userID,
+		Timezone: "UTC",
+		Locale:   "en",
+		Notifications: NotificationPreferences{
+			TaskAssigned:  true,
+			TaskDueSoon:   true,
+			TaskCommented: true,
+		},
+	}
+}
+
+// UserProfile is the authenticated user together with their preferences
+type UserProfile struct {
+	User
+	Preferences *UserPreferences `json:"preferences"`
+}
+
+// UpdateUserPreferencesRequest is a partial update, nil fields are left
+// unchanged. An empty display name clears the override
+type UpdateUserPreferencesRequest struct {
+	Timezone      *string                               `json:"timezone" validate:"omitempty,timezone"`
+	Locale        *string                               `json:"locale" validate:"omitempty,locale"`
+	DisplayName   *string                               `json:"display_name" validate:"omitempty,max=255"`
+	Notifications *UpdateNotificationPreferencesRequest `json:"notifications"`
+}
+
+type UpdateNotificationPreferencesRequest struct {
+	TaskAssigned  *bool `json:"task_assigned"`
+	TaskDueSoon   *bool `json:"task_due_soon"`
+	TaskCommented *bool `json:"task_commented"`
+	WeeklyDigest  *bool `json:"weekly_digest"`
+}
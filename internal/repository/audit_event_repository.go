@@ -0,0 +1,195 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/atavada/project-management-saas/internal/database"
+	"github.com/atavada/project-management-saas/internal/listing"
+	"github.com/atavada/project-management-saas/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const auditEventColumns = `id, organization_id, actor_type, actor_id, actor_name, entity_type, entity_id, action, changes, host(ip_address), request_id, created_at`
+
+// AuditListSpec is what the audit log of an organization can be filtered by.
+// The log only grows, so it is paged with cursors, newest first
+var AuditListSpec = listing.Spec{
+	Sorts: map[string]string{
+		"created_at": "created_at",
+	},
+	DefaultSort: []listing.Sort{{Field: "created_at", Desc: true}},
+	Tiebreak:    "id",
+	Filters: map[string]listing.Filter{
+		"actor_type": {
+			Column: "actor_type",
+			Op:     listing.Eq,
+			Type:   listing.Enum,
+			Values: []string{string(models.AuditActorUser), string(models.AuditActorServiceAccount), string(models.AuditActorWebhook)},
+			Cast:   "audit_actor_type",
+			Multi:  true,
+		},
+		"actor_id": {Column: "actor_id", Op: listing.Eq, Type: listing.UUID, Multi: true},
+		"entity_type": {
+			Column: "entity_type",
+			Op:     listing.Eq,
+			Type:   listing.Enum,
+			Values: []string{
+				string(models.AuditEntityUser),
+				string(models.AuditEntityUserPreferences),
+				string(models.AuditEntityOrganization),
+				string(models.AuditEntityOrganizationMember),
+				string(models.AuditEntityProject),
+				string(models.AuditEntityTask),
+				string(models.AuditEntityTaskComment),
+				string(models.AuditEntityDataExport),
+			},
+			Multi: true,
+		},
+		"entity_id": {Column: "entity_id", Op: listing.Eq, Type: listing.UUID, Multi: true},
+		"action": {
+			Column: "action",
+			Op:     listing.Eq,
+			Type:   listing.Enum,
+			Values: []string{string(models.AuditCreated), string(models.AuditUpdated), string(models.AuditDeleted)},
+			Cast:   "audit_action",
+			Multi:  true,
+		},
+		"created_after":  {Column: "created_at", Op: listing.Gte, Type: listing.Date},
+		"created_before": {Column: "created_at", Op: listing.Lte, Type: listing.Date},
+	},
+	Keyset: true,
+}
+
+// AuditEventRepository stores the audit log. Events are never changed once
+// written
+type AuditEventRepository interface {
+	Create(ctx context.Context, event *models.AuditEvent) (*models.AuditEvent, error)
+	ListForOrganization(ctx context.Context, orgID uuid.UUID, params listing.Params) (*listing.Page[models.AuditEvent], error)
+	ListForUser(ctx context.Context, userID uuid.UUID) ([]models.AuditEvent, error)
+}
+
+type auditEventRepository struct {
+	db *database.DB
+}
+
+func NewAuditEventRepository(db *database.DB) AuditEventRepository {
+	return &auditEventRepository{db: db}
+}
+
+// Create writes an event. Empty IP addresses and request IDs are stored as NULL
+func (r *auditEventRepository) Create(ctx context.Context, event *models.AuditEvent) (*models.AuditEvent, error) {
+	query := `
+		INSERT INTO audit_events (
+			organization_id, actor_type, actor_id, actor_name, entity_type, entity_id,
+			action, changes, ip_address, request_id
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NULLIF($9, '')::inet, NULLIF($10, ''))
+		RETURNING ` + auditEventColumns
+
+	var ip, requestID string
+	if event.IPAddress != nil {
+		ip = *event.IPAddress
+	}
+	if event.RequestID != nil {
+		requestID = *event.RequestID
+	}
+
+	changes := event.Changes
+	if changes == nil {
+		changes = map[string]models.AuditChange{}
+	}
+
+	var result models.AuditEvent
+	err := scanAuditEvent(r.db.Conn(ctx).QueryRow(
+		ctx,
+		query,
+		event.OrganizationID,
+		string(event.ActorType),
+		event.ActorID,
+		event.ActorName,
+		string(event.EntityType),
+		event.EntityID,
+		string(event.Action),
+		changes,
+		ip,
+		requestID,
+	), &result)
+
+	if err != nil {
+		return nil, fmt.Errorf("error creating audit event: %w", err)
+	}
+
+	return &result, nil
+}
+
+// ListForOrganization returns a page of the audit log of an organization
+func (r *auditEventRepository) ListForOrganization(ctx context.Context, orgID uuid.UUID, params listing.Params) (*listing.Page[models.AuditEvent], error) {
+	q := newListQuery("audit_events")
+	q.where("organization_id = " + q.arg(orgID))
+	q.apply(AuditListSpec, params)
+
+	page, err := list(ctx, r.db.ReadConn(ctx), q, auditEventColumns, AuditListSpec, params, scanAuditEvent)
+	if err != nil {
+		return nil, fmt.Errorf("error listing audit events: %w", err)
+	}
+
+	return page, nil
+}
+
+// ListForUser returns every event the user made, and every change to their
+// account and preferences, oldest first
+func (r *auditEventRepository) ListForUser(ctx context.Context, userID uuid.UUID) ([]models.AuditEvent, error) {
+	query := `
+		SELECT ` + auditEventColumns + `
+		FROM audit_events
+		WHERE actor_id = $1
+			OR (entity_type IN ($2, $3) AND entity_id = $1)
+		ORDER BY created_at, id
+	`
+
+	rows, err := r.db.ReadConn(ctx).Query(
+		ctx,
+		query,
+		userID,
+		string(models.AuditEntityUser),
+		string(models.AuditEntityUserPreferences),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error listing audit events for user: %w", err)
+	}
+	defer rows.Close()
+
+	var events []models.AuditEvent
+	for rows.Next() {
+		var event models.AuditEvent
+		if err := scanAuditEvent(rows, &event); err != nil {
+			return nil, fmt.Errorf("error scanning audit event: %w", err)
+		}
+		events = append(events, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating audit events: %w", err)
+	}
+
+	return events, nil
+}
+
+func scanAuditEvent(row pgx.Row, event *models.AuditEvent) error {
+	return row.Scan(
+		&event.ID,
+		&event.OrganizationID,
+		&event.ActorType,
+		&event.ActorID,
+		&event.ActorName,
+		&event.EntityType,
+		&event.EntityID,
+		&event.Action,
+		&event.Changes,
+		&event.IPAddress,
+		&event.RequestID,
+		&event.CreatedAt,
+	)
+}
@@ -0,0 +1,193 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/atavada/project-management-saas/internal/apperror"
+	"github.com/atavada/project-management-saas/internal/database"
+	"github.com/atavada/project-management-saas/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// DataExportRepository tracks data exports and stores their archives
+type DataExportRepository interface {
+	Create(ctx context.Context, userID uuid.UUID) (*models.DataExport, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*models.DataExport, error)
+	// GetArchive fails with NotFound unless the export completed
+	GetArchive(ctx context.Context, id uuid.UUID) ([]byte, error)
+	MarkProcessing(ctx context.Context, id uuid.UUID) error
+	Complete(ctx context.Context, id uuid.UUID, archive []byte) error
+	Fail(ctx context.Context, id uuid.UUID, reason string) error
+	// ListStale returns pending and processing exports last updated before the
+	// given time
+	ListStale(ctx context.Context, updatedBefore time.Time) ([]models.DataExport, error)
+}
+
+type dataExportRepository struct {
+	db *database.DB
+}
+
+func NewDataExportRepository(db *database.DB) DataExportRepository {
+	return &dataExportRepository{db: db}
+}
+
+func (r *dataExportRepository) Create(ctx context.Context, userID uuid.UUID) (*models.DataExport, error) {
+	query := `
+		INSERT INTO data_exports (user_id)
+		VALUES ($1)
+		RETURNING id, user_id, status, error, completed_at, created_at, updated_at
+	`
+
+	var export models.DataExport
+	err := r.db.Conn(ctx).QueryRow(ctx, query, userID).Scan(
+		&export.ID,
+		&export.UserID,
+		&export.Status,
+		&export.Error,
+		&export.CompletedAt,
+		&export.CreatedAt,
+		&export.UpdatedAt,
+	)
+
+	if err != nil {
+		return nil, fmt.Errorf("error creating data export: %w", err)
+	}
+
+	return &export, nil
+}
+
+func (r *dataExportRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.DataExport, error) {
+	query := `
+		SELECT id, user_id, status, error, completed_at, created_at, updated_at
+		FROM data_exports
+		WHERE id = $1
+	`
+
+	var export models.DataExport
+	err := r.db.Conn(ctx).QueryRow(ctx, query, id).Scan(
+		&export.ID,
+		&export.UserID,
+		&export.Status,
+		&export.Error,
+		&export.CompletedAt,
+		&export.CreatedAt,
+		&export.UpdatedAt,
+	)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, apperror.NotFound("Export not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting data export: %w", err)
+	}
+
+	return &export, nil
+}
+
+// GetArchive returns the archive of a completed export, nil if it has none
+func (r *dataExportRepository) GetArchive(ctx context.Context, id uuid.UUID) ([]byte, error) {
+	query := `
+		SELECT archive
+		FROM data_exports
+		WHERE id = $1 AND status = 'completed'
+	`
+
+	var archive []byte
+	err := r.db.Conn(ctx).QueryRow(ctx, query, id).Scan(&archive)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, apperror.NotFound("Export not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting data export archive: %w", err)
+	}
+
+	return archive, nil
+}
+
+func (r *dataExportRepository) MarkProcessing(ctx context.Context, id uuid.UUID) error {
+	query := `
+		UPDATE data_exports
+		SET status = 'processing'
+		WHERE id = $1
+	`
+
+	_, err := r.db.Conn(ctx).Exec(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("error updating data export: %w", err)
+	}
+
+	return nil
+}
+
+func (r *dataExportRepository) Complete(ctx context.Context, id uuid.UUID, archive []byte) error {
+	query := `
+		UPDATE data_exports
+		SET status = 'completed', archive = $2, error = NULL, completed_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+	`
+
+	_, err := r.db.Conn(ctx).Exec(ctx, query, id, archive)
+	if err != nil {
+		return fmt.Errorf("error completing data export: %w", err)
+	}
+
+	return nil
+}
+
+func (r *dataExportRepository) Fail(ctx context.Context, id uuid.UUID, reason string) error {
+	query := `
+		UPDATE data_exports
+		SET status = 'failed', error = $2, completed_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+	`
+
+	_, err := r.db.Conn(ctx).Exec(ctx, query, id, reason)
+	if err != nil {
+		return fmt.Errorf("error failing data export: %w", err)
+	}
+
+	return nil
+}
+
+func (r *dataExportRepository) ListStale(ctx context.Context, updatedBefore time.Time) ([]models.DataExport, error) {
+	query := `
+		SELECT id, user_id, status, error, completed_at, created_at, updated_at
+		FROM data_exports
+		WHERE status IN ('pending', 'processing') AND updated_at < $1
+		ORDER BY created_at
+	`
+
+	rows, err := r.db.Conn(ctx).Query(ctx, query, updatedBefore)
+	if err != nil {
+		return nil, fmt.Errorf("error listing stale data exports: %w", err)
+	}
+	defer rows.Close()
+
+	var exports []models.DataExport
+	for rows.Next() {
+		var export models.DataExport
+		if err := rows.Scan(
+			&export.ID,
+			&export.UserID,
+			&export.Status,
+			&export.Error,
+			&export.CompletedAt,
+			&export.CreatedAt,
+			&export.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("error scanning data export: %w", err)
+		}
+		exports = append(exports, export)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating data exports: %w", err)
+	}
+
+	return exports, nil
+}
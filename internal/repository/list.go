@@ -0,0 +1,365 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/atavada/project-management-saas/internal/apperror"
+	"github.com/atavada/project-management-saas/internal/database"
+	"github.com/atavada/project-management-saas/internal/listing"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// likeEscaper escapes the ILIKE wildcards in a search term
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// listQuery assembles the SELECT and COUNT of a paginated list. Conditions
+// added by the repository and those from listing filters share one argument
+// list; only placeholders are ever built from request values
+type listQuery struct {
+	from       string
+	conditions []string
+	args       []any
+}
+
+// newListQuery starts a list over from, the FROM clause including any joins
+func newListQuery(from string) *listQuery {
+	return &listQuery{from: from}
+}
+
+// arg adds a query argument and returns its placeholder
+func (q *listQuery) arg(value any) string {
+	q.args = append(q.args, value)
+	return "$" + strconv.Itoa(len(q.args))
+}
+
+func (q *listQuery) where(condition string) {
+	q.conditions = append(q.conditions, condition)
+}
+
+// apply adds the filters and search term of params
+func (q *listQuery) apply(spec listing.Spec, params listing.Params) {
+	// Sorted so the same request always produces the same SQL
+	names := make([]string, 0, len(params.Filters))
+	for name := range params.Filters {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		filter := spec.Filters[name]
+		values := params.Filters[name]
+
+		if filter.Multi {
+			placeholder := q.arg(typedSlice(filter.Type, values))
+			if filter.Cast != "" {
+				placeholder += "::text[]::" + filter.Cast + "[]"
+			}
+			q.where(filter.Column + " = ANY(" + placeholder + ")")
+			continue
+		}
+
+		placeholder := q.arg(values[0])
+		if filter.Cast != "" {
+			placeholder += "::" + filter.Cast
+		}
+		q.where(filter.Column + " " + string(filter.Op) + " " + placeholder)
+	}
+
+	if params.Search != "" && len(spec.Search) > 0 {
+		placeholder := q.arg("%" + likeEscaper.Replace(params.Search) + "%")
+		matches := make([]string, 0, len(spec.Search))
+		for _, column := range spec.Search {
+			matches = append(matches, column+" ILIKE "+placeholder)
+		}
+		q.where("(" + strings.Join(matches, " OR ") + ")")
+	}
+}
+
+func (q *listQuery) whereClause() string {
+	if len(q.conditions) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(q.conditions, " AND ")
+}
+
+func (q *listQuery) countSQL() string {
+	return "SELECT COUNT(*) FROM " + q.from + q.whereClause()
+}
+
+// selectSQL returns the page query and its arguments, which extend q.args with
+// the limit and offset
+func (q *listQuery) selectSQL(columns string, spec listing.Spec, params listing.Params) (string, []any) {
+	args := slices.Clone(q.args)
+	args = append(args, params.Limit, params.Offset())
+
+	query := "SELECT " + columns + " FROM " + q.from + q.whereClause() +
+		" ORDER BY " + orderBy(spec, params.Sort) +
+		" LIMIT $" + strconv.Itoa(len(args)-1) + " OFFSET $" + strconv.Itoa(len(args))
+
+	return query, args
+}
+
+// keysetQuery is one of the queries reading a keyset page. Its last
+// placeholder is the number of rows still wanted
+type keysetQuery struct {
+	sql  string
+	args []any
+}
+
+// keysetSQL returns the queries for a keyset page, to run in order until one
+// row more than the limit is read, which tells whether another page follows.
+// Rows with a key and rows with a NULL key, which sort last, are read by
+// separate queries: a row comparison (key, id) > ($1, $2) and key IS NULL can
+// each be answered by a range scan of an index on the key, a condition OR-ing
+// them together cannot. The sort key and tiebreak are selected as text to build
+// cursors from. Pages before a cursor are read in reverse order
+func (q *listQuery) keysetSQL(columns string, spec listing.Spec, params listing.Params) []keysetQuery {
+	sort := params.Sort[0]
+	column := spec.Sorts[sort.Field]
+	cursor := params.Cursor
+	reverse := cursor != nil && cursor.Before
+
+	// Rows following the cursor in the order read have a greater key, or the
+	// same key and a greater tiebreak
+	after, order := ">", " ASC"
+	if sort.Desc != reverse {
+		after, order = "<", " DESC"
+	}
+
+	// arg is the placeholder of the n-th argument a query adds to q's
+	arg := func(n int) string {
+		return "$" + strconv.Itoa(len(q.args)+n)
+	}
+	query := func(condition, orderBy string, args ...any) keysetQuery {
+		args = append(slices.Clone(q.args), args...)
+		conditions := append(slices.Clone(q.conditions), condition)
+
+		return keysetQuery{
+			sql: "SELECT " + columns + ", " + column + "::text, " + spec.Tiebreak + "::text" +
+				" FROM " + q.from + " WHERE " + strings.Join(conditions, " AND ") +
+				" ORDER BY " + orderBy +
+				" LIMIT $" + strconv.Itoa(len(args)+1),
+			args: args,
+		}
+	}
+
+	keyed := func(args ...any) keysetQuery {
+		condition := column + " IS NOT NULL"
+		if len(args) > 0 {
+			condition = "(" + column + ", " + spec.Tiebreak + ") " + after + " (" + arg(1) + ", " + arg(2) + ")"
+		}
+		return query(condition, column+order+", "+spec.Tiebreak+order, args...)
+	}
+	null := func(args ...any) keysetQuery {
+		condition := column + " IS NULL"
+		if len(args) > 0 {
+			condition += " AND " + spec.Tiebreak + " " + after + " " + arg(1)
+		}
+		return query(condition, spec.Tiebreak+order, args...)
+	}
+
+	switch {
+	case cursor == nil:
+		return []keysetQuery{keyed(), null()}
+	case cursor.Value == nil && !reverse:
+		return []keysetQuery{null(cursor.ID)}
+	case cursor.Value == nil:
+		return []keysetQuery{null(cursor.ID), keyed()}
+	case !reverse:
+		return []keysetQuery{keyed(*cursor.Value, cursor.ID), null()}
+	default:
+		return []keysetQuery{keyed(*cursor.Value, cursor.ID)}
+	}
+}
+
+// orderBy renders sort with the spec's tiebreak last, in the direction of the
+// last sort field
+func orderBy(spec listing.Spec, sort []listing.Sort) string {
+	terms := make([]string, 0, len(sort)+1)
+	desc := false
+	for _, s := range sort {
+		desc = s.Desc
+		terms = append(terms, spec.Sorts[s.Field]+direction(desc))
+	}
+	terms = append(terms, spec.Tiebreak+direction(desc))
+	return strings.Join(terms, ", ")
+}
+
+// direction renders an ORDER BY direction. Lists keep NULLs last
+func direction(desc bool) string {
+	if desc {
+		return " DESC NULLS LAST"
+	}
+	return " ASC NULLS LAST"
+}
+
+// keyedRow appends the sort key and tiebreak selected by keysetSQL to the
+// destinations of a row, so the repositories' scan functions work unchanged
+type keyedRow struct {
+	pgx.Row
+	key **string
+	id  *string
+}
+
+func (r keyedRow) Scan(dest ...any) error {
+	return r.Row.Scan(append(dest, r.key, r.id)...)
+}
+
+// list runs q on conn and scans one page of rows, paging by cursor or by
+// page number as params asks
+func list[T any](
+	ctx context.Context,
+	conn database.Querier,
+	q *listQuery,
+	columns string,
+	spec listing.Spec,
+	params listing.Params,
+	scan func(pgx.Row, *T) error,
+) (*listing.Page[T], error) {
+	if params.Keyset {
+		return listKeyset(ctx, conn, q, columns, spec, params, scan)
+	}
+
+	var total int64
+	if err := conn.QueryRow(ctx, q.countSQL(), q.args...).Scan(&total); err != nil {
+		return nil, fmt.Errorf("error counting rows: %w", err)
+	}
+
+	query, args := q.selectSQL(columns, spec, params)
+	rows, err := conn.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]T, 0, params.Limit)
+	for rows.Next() {
+		var item T
+		if err := scan(rows, &item); err != nil {
+			return nil, fmt.Errorf("error scanning row: %w", err)
+		}
+		items = append(items, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return &listing.Page[T]{Items: items, Total: total}, nil
+}
+
+func listKeyset[T any](
+	ctx context.Context,
+	conn database.Querier,
+	q *listQuery,
+	columns string,
+	spec listing.Spec,
+	params listing.Params,
+	scan func(pgx.Row, *T) error,
+) (*listing.Page[T], error) {
+	items := make([]T, 0, params.Limit+1)
+	keys := make([]*string, 0, params.Limit+1)
+	ids := make([]string, 0, params.Limit+1)
+	for _, query := range q.keysetSQL(columns, spec, params) {
+		wanted := params.Limit + 1 - len(items)
+		if wanted == 0 {
+			break
+		}
+
+		rows, err := conn.Query(ctx, query.sql, append(query.args, wanted)...)
+		if err != nil {
+			return nil, keysetError(err)
+		}
+
+		for rows.Next() {
+			var item T
+			var key *string
+			var id string
+			if err := scan(keyedRow{Row: rows, key: &key, id: &id}, &item); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("error scanning row: %w", err)
+			}
+			items = append(items, item)
+			keys = append(keys, key)
+			ids = append(ids, id)
+		}
+
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, keysetError(fmt.Errorf("error iterating rows: %w", err))
+		}
+	}
+
+	// The extra row only says there is more in the direction read
+	more := len(items) > params.Limit
+	if more {
+		items, keys, ids = items[:params.Limit], keys[:params.Limit], ids[:params.Limit]
+	}
+
+	backwards := params.Cursor != nil && params.Cursor.Before
+	if backwards {
+		slices.Reverse(items)
+		slices.Reverse(keys)
+		slices.Reverse(ids)
+	}
+
+	page := &listing.Page[T]{Items: items}
+	if len(items) == 0 {
+		return page, nil
+	}
+
+	sort := params.Sort[0]
+	cursor := func(i int, before bool) string {
+		return listing.Cursor{Sort: sort.Field, Desc: sort.Desc, Value: keys[i], ID: ids[i], Before: before}.Encode()
+	}
+
+	// Reading forwards from a cursor means there's a page behind it, and
+	// reading backwards means there's one ahead
+	if more || backwards {
+		page.Next = cursor(len(items)-1, false)
+	}
+	if (backwards && more) || (!backwards && params.Cursor != nil) {
+		page.Prev = cursor(0, true)
+	}
+
+	return page, nil
+}
+
+// keysetError reports a cursor edited into a key Postgres can't parse as the
+// client's mistake
+func keysetError(err error) error {
+	if isDataException(err) {
+		return apperror.BadRequest("Invalid cursor").Wrap(err)
+	}
+	return err
+}
+
+// typedSlice converts parsed filter values to a slice pgx encodes as an array
+func typedSlice(t listing.Type, values []any) any {
+	switch t {
+	case listing.UUID:
+		ids := make([]uuid.UUID, len(values))
+		for i, v := range values {
+			ids[i] = v.(uuid.UUID)
+		}
+		return ids
+	case listing.Date:
+		times := make([]time.Time, len(values))
+		for i, v := range values {
+			times[i] = v.(time.Time)
+		}
+		return times
+	default:
+		strs := make([]string, len(values))
+		for i, v := range values {
+			strs[i] = v.(string)
+		}
+		return strs
+	}
+}
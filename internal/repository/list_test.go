@@ -0,0 +1,173 @@
+package repository
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/atavada/project-management-saas/internal/apperror"
+	"github.com/atavada/project-management-saas/internal/listing"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+var dueDateSpec = listing.Spec{
+	Sorts:    map[string]string{"due_date": "t.due_date"},
+	Tiebreak: "t.id",
+	Keyset:   true,
+}
+
+func TestKeysetSQL(t *testing.T) {
+	projectID := uuid.New()
+	id := uuid.NewString()
+	due := "2026-03-02"
+
+	// query is the SQL keysetSQL builds around condition, after the project
+	// condition the repository added as $1
+	query := func(condition, orderBy, limit string) string {
+		return "SELECT t.id, t.title, t.due_date::text, t.id::text FROM tasks t" +
+			" WHERE t.project_id = $1 AND " + condition +
+			" ORDER BY " + orderBy + " LIMIT " + limit
+	}
+	keyed := func(desc bool) keysetQuery {
+		order := "t.due_date ASC, t.id ASC"
+		if desc {
+			order = "t.due_date DESC, t.id DESC"
+		}
+		return keysetQuery{sql: query("t.due_date IS NOT NULL", order, "$2"), args: []any{projectID}}
+	}
+	null := func(desc bool) keysetQuery {
+		order := "t.id ASC"
+		if desc {
+			order = "t.id DESC"
+		}
+		return keysetQuery{sql: query("t.due_date IS NULL", order, "$2"), args: []any{projectID}}
+	}
+
+	tests := []struct {
+		name   string
+		desc   bool
+		cursor *listing.Cursor
+		want   []keysetQuery
+	}{
+		{
+			name: "first page ascending",
+			want: []keysetQuery{keyed(false), null(false)},
+		},
+		{
+			name: "first page descending",
+			desc: true,
+			want: []keysetQuery{keyed(true), null(true)},
+		},
+		{
+			name:   "after ascending",
+			cursor: &listing.Cursor{Value: &due, ID: id},
+			want: []keysetQuery{
+				{sql: query("(t.due_date, t.id) > ($2, $3)", "t.due_date ASC, t.id ASC", "$4"), args: []any{projectID, due, id}},
+				null(false),
+			},
+		},
+		{
+			name:   "after descending",
+			desc:   true,
+			cursor: &listing.Cursor{Desc: true, Value: &due, ID: id},
+			want: []keysetQuery{
+				{sql: query("(t.due_date, t.id) < ($2, $3)", "t.due_date DESC, t.id DESC", "$4"), args: []any{projectID, due, id}},
+				null(true),
+			},
+		},
+		{
+			name:   "before ascending",
+			cursor: &listing.Cursor{Value: &due, ID: id, Before: true},
+			want: []keysetQuery{
+				{sql: query("(t.due_date, t.id) < ($2, $3)", "t.due_date DESC, t.id DESC", "$4"), args: []any{projectID, due, id}},
+			},
+		},
+		{
+			name:   "before descending",
+			desc:   true,
+			cursor: &listing.Cursor{Desc: true, Value: &due, ID: id, Before: true},
+			want: []keysetQuery{
+				{sql: query("(t.due_date, t.id) > ($2, $3)", "t.due_date ASC, t.id ASC", "$4"), args: []any{projectID, due, id}},
+			},
+		},
+		{
+			name:   "after NULL ascending",
+			cursor: &listing.Cursor{ID: id},
+			want: []keysetQuery{
+				{sql: query("t.due_date IS NULL AND t.id > $2", "t.id ASC", "$3"), args: []any{projectID, id}},
+			},
+		},
+		{
+			name:   "after NULL descending",
+			desc:   true,
+			cursor: &listing.Cursor{Desc: true, ID: id},
+			want: []keysetQuery{
+				{sql: query("t.due_date IS NULL AND t.id < $2", "t.id DESC", "$3"), args: []any{projectID, id}},
+			},
+		},
+		{
+			name:   "before NULL ascending",
+			cursor: &listing.Cursor{ID: id, Before: true},
+			want: []keysetQuery{
+				{sql: query("t.due_date IS NULL AND t.id < $2", "t.id DESC", "$3"), args: []any{projectID, id}},
+				keyed(true),
+			},
+		},
+		{
+			name:   "before NULL descending",
+			desc:   true,
+			cursor: &listing.Cursor{Desc: true, ID: id, Before: true},
+			want: []keysetQuery{
+				{sql: query("t.due_date IS NULL AND t.id > $2", "t.id ASC", "$3"), args: []any{projectID, id}},
+				keyed(false),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.cursor != nil {
+				tt.cursor.Sort = "due_date"
+			}
+			params := listing.Params{
+				Limit:  20,
+				Sort:   []listing.Sort{{Field: "due_date", Desc: tt.desc}},
+				Keyset: true,
+				Cursor: tt.cursor,
+			}
+
+			q := newListQuery("tasks t")
+			q.where("t.project_id = " + q.arg(projectID))
+
+			got := q.keysetSQL("t.id, t.title", dueDateSpec, params)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeysetErrorBlamesTheCursor(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+	}{
+		{"unparsable key", &pgconn.PgError{Code: "22007"}, http.StatusBadRequest},
+		{"connection lost", &pgconn.PgError{Code: "08006"}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			appErr, ok := apperror.As(keysetError(tt.err))
+			status := 0
+			if ok {
+				status = appErr.Status()
+			}
+			if status != tt.status {
+				t.Errorf("got status %d, want %d", status, tt.status)
+			}
+		})
+	}
+}
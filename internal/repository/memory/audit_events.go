@@ -0,0 +1,89 @@
+package memory
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/atavada/project-management-saas/internal/listing"
+	"github.com/atavada/project-management-saas/internal/models"
+	"github.com/google/uuid"
+)
+
+type auditEventRepository struct {
+	s *Store
+}
+
+func (r *auditEventRepository) Create(ctx context.Context, event *models.AuditEvent) (*models.AuditEvent, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	created := *event
+	created.ID = uuid.New()
+	created.Changes = maps.Clone(event.Changes)
+	if created.Changes == nil {
+		created.Changes = map[string]models.AuditChange{}
+	}
+	if created.IPAddress != nil && *created.IPAddress == "" {
+		created.IPAddress = nil
+	}
+	if created.RequestID != nil && *created.RequestID == "" {
+		created.RequestID = nil
+	}
+	created.CreatedAt = r.s.Now()
+	r.s.auditEvents[created.ID] = created
+
+	return &created, nil
+}
+
+func (r *auditEventRepository) ListForOrganization(ctx context.Context, orgID uuid.UUID, params listing.Params) (*listing.Page[models.AuditEvent], error) {
+	r.s.mu.RLock()
+	events := make([]models.AuditEvent, 0)
+	for _, event := range r.s.auditEvents {
+		if event.OrganizationID != nil && *event.OrganizationID == orgID {
+			events = append(events, event)
+		}
+	}
+	r.s.mu.RUnlock()
+
+	return page(events, params, columns[models.AuditEvent]{
+		id: func(e models.AuditEvent) uuid.UUID { return e.ID },
+		sorts: map[string]func(models.AuditEvent) *string{
+			"created_at": func(e models.AuditEvent) *string { return timeKey(e.CreatedAt) },
+		},
+		filters: map[string]func(models.AuditEvent, []any) bool{
+			"actor_type":     func(e models.AuditEvent, values []any) bool { return enumEqualsAny(e.ActorType, values) },
+			"actor_id":       func(e models.AuditEvent, values []any) bool { return optionalEqualsAny(e.ActorID, values) },
+			"entity_type":    func(e models.AuditEvent, values []any) bool { return enumEqualsAny(e.EntityType, values) },
+			"entity_id":      func(e models.AuditEvent, values []any) bool { return equalsAny(e.EntityID, values) },
+			"action":         func(e models.AuditEvent, values []any) bool { return enumEqualsAny(e.Action, values) },
+			"created_after":  func(e models.AuditEvent, values []any) bool { return onOrAfter(&e.CreatedAt, values) },
+			"created_before": func(e models.AuditEvent, values []any) bool { return onOrBefore(&e.CreatedAt, values) },
+		},
+	}), nil
+}
+
+func (r *auditEventRepository) ListForUser(ctx context.Context, userID uuid.UUID) ([]models.AuditEvent, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	events := make([]models.AuditEvent, 0)
+	for _, event := range r.s.auditEvents {
+		actor := event.ActorID != nil && *event.ActorID == userID
+		subject := (event.EntityType == models.AuditEntityUser || event.EntityType == models.AuditEntityUserPreferences) &&
+			event.EntityID == userID
+		if actor || subject {
+			events = append(events, event)
+		}
+	}
+
+	slices.SortFunc(events, func(a, b models.AuditEvent) int {
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID.String(), b.ID.String())
+	})
+
+	return events, nil
+}
@@ -0,0 +1,328 @@
+package memory_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/atavada/project-management-saas/internal/apperror"
+	"github.com/atavada/project-management-saas/internal/listing"
+	"github.com/atavada/project-management-saas/internal/models"
+	"github.com/atavada/project-management-saas/internal/repository"
+	"github.com/atavada/project-management-saas/internal/repository/memory"
+	"github.com/atavada/project-management-saas/internal/testenv"
+	"github.com/google/uuid"
+)
+
+// repos are the repositories a contract runs against
+type repos struct {
+	users    repository.UserRepository
+	orgs     repository.OrganizationRepository
+	members  repository.OrganizationMemberRepository
+	projects repository.ProjectRepository
+	tasks    repository.TaskRepository
+	activity repository.TaskActivityRepository
+	audit    repository.AuditEventRepository
+}
+
+// contract runs fn against the fakes and against Postgres, so the fakes can't
+// drift from the SQL they stand in for. The Postgres run is skipped without
+// DATABASE_URL
+func contract(t *testing.T, fn func(t *testing.T, r repos)) {
+	t.Run("memory", func(t *testing.T) {
+		store := memory.NewStore()
+		fn(t, repos{
+			users:    store.Users(),
+			orgs:     store.Organizations(),
+			members:  store.Members(),
+			projects: store.Projects(),
+			tasks:    store.Tasks(),
+			activity: store.TaskActivity(),
+			audit:    store.AuditEvents(),
+		})
+	})
+
+	t.Run("postgres", func(t *testing.T) {
+		db, _ := testenv.NewDatabase(t)
+		fn(t, repos{
+			users:    repository.NewUserRepository(db),
+			orgs:     repository.NewOrganizationRepository(db),
+			members:  repository.NewOrganizationMemberRepository(db),
+			projects: repository.NewProjectRepository(db),
+			tasks:    repository.NewTaskRepository(db),
+			activity: repository.NewTaskActivityRepository(db),
+			audit:    repository.NewAuditEventRepository(db),
+		})
+	})
+}
+
+func TestTaskUpdateTracksCompletion(t *testing.T) {
+	contract(t, func(t *testing.T, r repos) {
+		ctx := context.Background()
+		user, org := seed(t, r)
+		task := seedTask(t, r, org.ID, user.ID)
+
+		task.Status = models.TaskStatusDone
+		done, err := r.tasks.Update(ctx, task)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if done.CompletedAt == nil {
+			t.Fatal("moving to done did not stamp completed_at")
+		}
+
+		done.Title = "Renamed"
+		again, err := r.tasks.Update(ctx, done)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if again.CompletedAt == nil || !again.CompletedAt.Equal(*done.CompletedAt) {
+			t.Errorf("saving a done task moved completed_at from %v to %v", done.CompletedAt, again.CompletedAt)
+		}
+
+		again.Status = models.TaskStatusInProgress
+		reopened, err := r.tasks.Update(ctx, again)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if reopened.CompletedAt != nil {
+			t.Errorf("reopening kept completed_at %v", reopened.CompletedAt)
+		}
+
+		locked, err := r.tasks.GetByIDForUpdate(ctx, task.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if locked.Title != "Renamed" || locked.Status != models.TaskStatusInProgress {
+			t.Errorf("got %q %s", locked.Title, locked.Status)
+		}
+
+		task.ID = uuid.New()
+		if _, err := r.tasks.Update(ctx, task); !errors.Is(err, apperror.ErrNotFound) {
+			t.Errorf("updating a missing task returned %v, want not found", err)
+		}
+	})
+}
+
+func TestMemberUpsertKeepsOwners(t *testing.T) {
+	contract(t, func(t *testing.T, r repos) {
+		ctx := context.Background()
+		owner, org := seed(t, r)
+		member := seedUser(t, r, "user_member")
+
+		for _, m := range []models.OrganizationMember{
+			{OrganizationID: org.ID, UserID: owner.ID, Role: models.RoleOwner},
+			{OrganizationID: org.ID, UserID: member.ID, Role: models.RoleMember, ClerkMembershipID: "orgmem_1"},
+		} {
+			if err := r.members.Create(ctx, &m); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		for _, m := range []models.OrganizationMember{
+			{OrganizationID: org.ID, UserID: owner.ID, Role: models.RoleAdmin, ClerkMembershipID: "orgmem_0"},
+			{OrganizationID: org.ID, UserID: member.ID, Role: models.RoleAdmin},
+		} {
+			if err := r.members.Upsert(ctx, &m); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		got, err := r.members.GetMember(ctx, org.ID, owner.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Role != models.RoleOwner || got.ClerkMembershipID != "orgmem_0" {
+			t.Errorf("owner is now %s with membership %q, want owner with orgmem_0", got.Role, got.ClerkMembershipID)
+		}
+
+		got, err = r.members.GetMember(ctx, org.ID, member.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Role != models.RoleAdmin || got.ClerkMembershipID != "orgmem_1" {
+			t.Errorf("member is now %s with membership %q, want admin with orgmem_1", got.Role, got.ClerkMembershipID)
+		}
+	})
+}
+
+func TestUserAnonymizeRemovesMemberships(t *testing.T) {
+	contract(t, func(t *testing.T, r repos) {
+		ctx := context.Background()
+		user, org := seed(t, r)
+		if err := r.members.Create(ctx, &models.OrganizationMember{OrganizationID: org.ID, UserID: user.ID, Role: models.RoleOwner}); err != nil {
+			t.Fatal(err)
+		}
+
+		anonymized, err := r.users.Anonymize(ctx, user.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := models.User{
+			ClerkUserID: "deleted_" + user.ID.String(),
+			Email:       user.ID.String() + "@deleted.invalid",
+			FirstName:   "Deleted",
+			LastName:    "User",
+		}
+		if anonymized.ClerkUserID != want.ClerkUserID || anonymized.Email != want.Email ||
+			anonymized.FirstName != want.FirstName || anonymized.LastName != want.LastName || anonymized.AvatarURL != "" {
+			t.Errorf("got %+v, want the personal data replaced like %+v", anonymized, want)
+		}
+
+		if _, err := r.users.GetByClerkID(ctx, user.ClerkUserID); !errors.Is(err, apperror.ErrNotFound) {
+			t.Errorf("lookup by the old Clerk ID returned %v, want not found", err)
+		}
+
+		memberships, err := r.members.ListByUser(ctx, user.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(memberships) != 0 {
+			t.Errorf("%d memberships were kept", len(memberships))
+		}
+	})
+}
+
+func TestUserAnonymizeClearsAuditActor(t *testing.T) {
+	contract(t, func(t *testing.T, r repos) {
+		ctx := context.Background()
+		user, org := seed(t, r)
+		other := seedUser(t, r, "user_other")
+
+		ip := "203.0.113.7"
+		for _, event := range []models.AuditEvent{
+			// Recorded by UUID, and by Clerk ID before the user was synced
+			{OrganizationID: &org.ID, ActorType: models.AuditActorUser, ActorID: &user.ID, IPAddress: &ip},
+			{OrganizationID: &org.ID, ActorType: models.AuditActorUser, ActorName: user.ClerkUserID, IPAddress: &ip},
+			{OrganizationID: &org.ID, ActorType: models.AuditActorUser, ActorID: &other.ID, IPAddress: &ip},
+		} {
+			event.EntityType = models.AuditEntityOrganization
+			event.EntityID = org.ID
+			event.Action = models.AuditUpdated
+			if _, err := r.audit.Create(ctx, &event); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if _, err := r.users.Anonymize(ctx, user.ID); err != nil {
+			t.Fatal(err)
+		}
+
+		params, err := listing.Parse(map[string]string{}, repository.AuditListSpec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		page, err := r.audit.ListForOrganization(ctx, org.ID, params)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		kept := 0
+		for _, event := range page.Items {
+			if event.ActorID != nil && *event.ActorID == other.ID {
+				kept++
+				if event.IPAddress == nil {
+					t.Error("the IP address of another user's event was cleared")
+				}
+				continue
+			}
+			if event.ActorName != "" || event.IPAddress != nil {
+				t.Errorf("erased user's event kept actor name %q and IP address %v", event.ActorName, event.IPAddress)
+			}
+		}
+		if len(page.Items) != 3 || kept != 1 {
+			t.Errorf("got %d events, %d by the other user", len(page.Items), kept)
+		}
+	})
+}
+
+func TestDataExportSources(t *testing.T) {
+	contract(t, func(t *testing.T, r repos) {
+		ctx := context.Background()
+		user, org := seed(t, r)
+		other := seedUser(t, r, "user_other")
+		task := seedTask(t, r, org.ID, user.ID)
+
+		body := "Looks good"
+		for _, activity := range []models.TaskActivity{
+			{TaskID: task.ID, ActorID: &user.ID, Type: models.TaskActivityCommented, Body: &body},
+			{TaskID: task.ID, ActorID: &other.ID, Type: models.TaskActivityCommented, Body: &body},
+		} {
+			if _, err := r.activity.Create(ctx, &activity); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		activity, err := r.activity.ListByActor(ctx, user.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(activity) != 1 || activity[0].Actor == nil || activity[0].Actor.ID != user.ID {
+			t.Errorf("got %+v, want the user's comment with its actor", activity)
+		}
+
+		for _, event := range []models.AuditEvent{
+			{ActorType: models.AuditActorUser, ActorID: &user.ID, EntityType: models.AuditEntityTask, EntityID: task.ID, Action: models.AuditUpdated},
+			{ActorType: models.AuditActorWebhook, ActorName: "clerk", EntityType: models.AuditEntityUser, EntityID: user.ID, Action: models.AuditUpdated},
+			{ActorType: models.AuditActorUser, ActorID: &other.ID, EntityType: models.AuditEntityUser, EntityID: other.ID, Action: models.AuditUpdated},
+		} {
+			if _, err := r.audit.Create(ctx, &event); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		events, err := r.audit.ListForUser(ctx, user.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(events) != 2 {
+			t.Errorf("got %d events, want the one the user made and the one about them", len(events))
+		}
+	})
+}
+
+func seed(t *testing.T, r repos) (*models.User, *models.Organization) {
+	t.Helper()
+
+	user := seedUser(t, r, "user_1")
+
+	org, err := r.orgs.Create(context.Background(), &models.CreateOrganizationRequest{ClerkOrgID: "org_1", Name: "Acme", Slug: "acme"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return user, org
+}
+
+func seedUser(t *testing.T, r repos, clerkUserID string) *models.User {
+	t.Helper()
+
+	user, err := r.users.Create(context.Background(), &models.CreateUserRequest{
+		ClerkUserID: clerkUserID,
+		Email:       clerkUserID + "@example.com",
+		FirstName:   "Test",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return user
+}
+
+func seedTask(t *testing.T, r repos, orgID, createdBy uuid.UUID) *models.Task {
+	t.Helper()
+
+	ctx := context.Background()
+
+	project, err := r.projects.Create(ctx, &models.CreateProjectRequest{OrganizationID: orgID, Name: "Launch"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	task, err := r.tasks.Create(ctx, &models.CreateTaskRequest{ProjectID: project.ID, CreatedBy: createdBy, Title: "Write the announcement"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return task
+}
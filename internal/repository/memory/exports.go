@@ -0,0 +1,124 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/atavada/project-management-saas/internal/apperror"
+	"github.com/atavada/project-management-saas/internal/models"
+	"github.com/google/uuid"
+)
+
+// dataExport is a stored export with the archive the model leaves out
+type dataExport struct {
+	models.DataExport
+	archive []byte
+}
+
+type dataExportRepository struct {
+	s *Store
+}
+
+func (r *dataExportRepository) Create(ctx context.Context, userID uuid.UUID) (*models.DataExport, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	now := r.s.Now()
+	export := models.DataExport{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Status:    models.DataExportPending,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	r.s.exports[export.ID] = dataExport{DataExport: export}
+
+	return &export, nil
+}
+
+func (r *dataExportRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.DataExport, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	export, ok := r.s.exports[id]
+	if !ok {
+		return nil, apperror.NotFound("Export not found")
+	}
+
+	return &export.DataExport, nil
+}
+
+func (r *dataExportRepository) GetArchive(ctx context.Context, id uuid.UUID) ([]byte, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	export, ok := r.s.exports[id]
+	if !ok || export.Status != models.DataExportCompleted {
+		return nil, apperror.NotFound("Export not found")
+	}
+
+	return slices.Clone(export.archive), nil
+}
+
+func (r *dataExportRepository) MarkProcessing(ctx context.Context, id uuid.UUID) error {
+	r.update(id, func(export *dataExport) {
+		export.Status = models.DataExportProcessing
+	})
+	return nil
+}
+
+func (r *dataExportRepository) Complete(ctx context.Context, id uuid.UUID, archive []byte) error {
+	r.update(id, func(export *dataExport) {
+		now := r.s.Now()
+		export.Status = models.DataExportCompleted
+		export.archive = slices.Clone(archive)
+		export.Error = nil
+		export.CompletedAt = &now
+	})
+	return nil
+}
+
+func (r *dataExportRepository) Fail(ctx context.Context, id uuid.UUID, reason string) error {
+	r.update(id, func(export *dataExport) {
+		now := r.s.Now()
+		export.Status = models.DataExportFailed
+		export.Error = &reason
+		export.CompletedAt = &now
+	})
+	return nil
+}
+
+func (r *dataExportRepository) ListStale(ctx context.Context, updatedBefore time.Time) ([]models.DataExport, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	var exports []models.DataExport
+	for _, export := range r.s.exports {
+		unfinished := export.Status == models.DataExportPending || export.Status == models.DataExportProcessing
+		if unfinished && export.UpdatedAt.Before(updatedBefore) {
+			exports = append(exports, export.DataExport)
+		}
+	}
+	slices.SortFunc(exports, func(a, b models.DataExport) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+
+	return exports, nil
+}
+
+// update changes an export in place, missing exports are ignored like an
+// UPDATE matching no rows
+func (r *dataExportRepository) update(id uuid.UUID, change func(*dataExport)) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	export, ok := r.s.exports[id]
+	if !ok {
+		return
+	}
+
+	change(&export)
+	export.UpdatedAt = r.s.Now()
+	r.s.exports[id] = export
+}
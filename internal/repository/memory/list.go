@@ -0,0 +1,209 @@
+package memory
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/atavada/project-management-saas/internal/listing"
+	"github.com/google/uuid"
+)
+
+// columns tells page how to read the records of one list for the names used
+// by its listing.Spec
+type columns[T any] struct {
+	id func(T) uuid.UUID
+	// sorts return a key that orders like the column, nil for NULL
+	sorts map[string]func(T) *string
+	// filters report whether a record matches the parsed filter values
+	filters map[string]func(T, []any) bool
+	// search returns the texts the q parameter is matched against
+	search func(T) []string
+}
+
+// page filters, sorts and pages items the way the Postgres list query does,
+// by page number or by cursor as params asks
+func page[T any](items []T, params listing.Params, cols columns[T]) *listing.Page[T] {
+	matched := make([]T, 0, len(items))
+	for _, item := range items {
+		if matches(item, params, cols) {
+			matched = append(matched, item)
+		}
+	}
+
+	slices.SortFunc(matched, func(a, b T) int {
+		return compareKeys(keysOf(a, params.Sort, cols), keysOf(b, params.Sort, cols), params.Sort)
+	})
+
+	if !params.Keyset {
+		total := int64(len(matched))
+		start := min(params.Offset(), len(matched))
+		end := min(start+params.Limit, len(matched))
+		return &listing.Page[T]{Items: matched[start:end], Total: total}
+	}
+
+	return keysetPage(matched, params, cols)
+}
+
+func keysetPage[T any](sorted []T, params listing.Params, cols columns[T]) *listing.Page[T] {
+	start, end := 0, len(sorted)
+	backwards := false
+
+	if c := params.Cursor; c != nil {
+		at := rowKeys{values: []*string{c.Value}, id: c.ID}
+		// First row after the cursor
+		split := len(sorted)
+		for i, item := range sorted {
+			if compareKeys(keysOf(item, params.Sort, cols), at, params.Sort) > 0 {
+				split = i
+				break
+			}
+		}
+
+		if c.Before {
+			backwards = true
+			end = split
+			for end > 0 && compareKeys(keysOf(sorted[end-1], params.Sort, cols), at, params.Sort) >= 0 {
+				end--
+			}
+		} else {
+			start = split
+		}
+	}
+
+	// more says whether rows are left beyond the page in the direction read
+	more := end-start > params.Limit
+	items := sorted[start:min(end, start+params.Limit)]
+	if backwards {
+		items = sorted[max(start, end-params.Limit):end]
+	}
+
+	result := &listing.Page[T]{Items: items}
+	if len(items) == 0 {
+		return result
+	}
+
+	sort := params.Sort[0]
+	cursor := func(item T, before bool) string {
+		keys := keysOf(item, params.Sort, cols)
+		return listing.Cursor{Sort: sort.Field, Desc: sort.Desc, Value: keys.values[0], ID: keys.id, Before: before}.Encode()
+	}
+
+	if more || backwards {
+		result.Next = cursor(items[len(items)-1], false)
+	}
+	if (backwards && more) || (!backwards && params.Cursor != nil) {
+		result.Prev = cursor(items[0], true)
+	}
+
+	return result
+}
+
+func matches[T any](item T, params listing.Params, cols columns[T]) bool {
+	for name, values := range params.Filters {
+		if match, ok := cols.filters[name]; ok && !match(item, values) {
+			return false
+		}
+	}
+
+	if params.Search != "" && cols.search != nil {
+		term := strings.ToLower(params.Search)
+		for _, text := range cols.search(item) {
+			if strings.Contains(strings.ToLower(text), term) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return true
+}
+
+type rowKeys struct {
+	values []*string
+	id     string
+}
+
+func keysOf[T any](item T, sort []listing.Sort, cols columns[T]) rowKeys {
+	keys := rowKeys{values: make([]*string, len(sort)), id: cols.id(item).String()}
+	for i, s := range sort {
+		keys.values[i] = cols.sorts[s.Field](item)
+	}
+	return keys
+}
+
+// compareKeys orders like ORDER BY with NULLS LAST and the id as tiebreak in
+// the direction of the last sort field
+func compareKeys(a, b rowKeys, sort []listing.Sort) int {
+	desc := false
+	for i, s := range sort {
+		desc = s.Desc
+		av, bv := a.values[i], b.values[i]
+		switch {
+		case av == nil && bv == nil:
+			continue
+		case av == nil:
+			return 1
+		case bv == nil:
+			return -1
+		}
+		if c := strings.Compare(*av, *bv); c != 0 {
+			if desc {
+				return -c
+			}
+			return c
+		}
+	}
+
+	c := strings.Compare(a.id, b.id)
+	if desc {
+		return -c
+	}
+	return c
+}
+
+// Sort keys
+
+func text(s string) *string {
+	return &s
+}
+
+func timeKey(t time.Time) *string {
+	return text(t.UTC().Format("2006-01-02T15:04:05.000000000"))
+}
+
+func optionalTimeKey(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+	return timeKey(*t)
+}
+
+// rankKey orders enum values by their position in order, like Postgres does
+func rankKey[E ~string](value E, order ...E) *string {
+	return text(strconv.Itoa(slices.Index(order, value)))
+}
+
+// Filter predicates
+
+func equalsAny[V comparable](value V, values []any) bool {
+	return slices.Contains(values, any(value))
+}
+
+// enumEqualsAny matches enums, whose filter values are parsed as plain strings
+func enumEqualsAny[E ~string](value E, values []any) bool {
+	return equalsAny(string(value), values)
+}
+
+func optionalEqualsAny(value *uuid.UUID, values []any) bool {
+	return value != nil && equalsAny(*value, values)
+}
+
+func onOrAfter(t *time.Time, values []any) bool {
+	return t != nil && !t.Before(values[0].(time.Time))
+}
+
+func onOrBefore(t *time.Time, values []any) bool {
+	return t != nil && !t.After(values[0].(time.Time))
+}
@@ -0,0 +1,166 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/atavada/project-management-saas/internal/apperror"
+	"github.com/atavada/project-management-saas/internal/listing"
+	"github.com/atavada/project-management-saas/internal/models"
+	"github.com/google/uuid"
+)
+
+type organizationMemberRepository struct {
+	s *Store
+}
+
+// Create adds a membership, an existing one is left as it is
+func (r *organizationMemberRepository) Create(ctx context.Context, member *models.OrganizationMember) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for _, existing := range r.s.members {
+		if existing.OrganizationID == member.OrganizationID && existing.UserID == member.UserID {
+			return nil
+		}
+		if member.ClerkMembershipID != "" && existing.ClerkMembershipID == member.ClerkMembershipID {
+			return fmt.Errorf("error creating organization member: duplicate clerk membership %s", member.ClerkMembershipID)
+		}
+	}
+
+	r.insert(member.OrganizationID, member.UserID, member.Role, member.ClerkMembershipID)
+	return nil
+}
+
+// Upsert adds a membership or updates the role and Clerk membership ID of an
+// existing one. Owners keep their role
+func (r *organizationMemberRepository) Upsert(ctx context.Context, member *models.OrganizationMember) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	existing, ok := r.find(member.OrganizationID, member.UserID)
+	if !ok {
+		r.insert(member.OrganizationID, member.UserID, member.Role, member.ClerkMembershipID)
+		return nil
+	}
+
+	if existing.Role != models.RoleOwner {
+		existing.Role = member.Role
+	}
+	if member.ClerkMembershipID != "" {
+		existing.ClerkMembershipID = member.ClerkMembershipID
+	}
+	existing.UpdatedAt = r.s.Now()
+	r.s.members[existing.ID] = existing
+
+	return nil
+}
+
+func (r *organizationMemberRepository) GetMember(ctx context.Context, orgID, userID uuid.UUID) (*models.OrganizationMember, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	if member, ok := r.find(orgID, userID); ok {
+		return &member, nil
+	}
+
+	return nil, apperror.NotFound("Membership not found")
+}
+
+func (r *organizationMemberRepository) Delete(ctx context.Context, orgID, userID uuid.UUID) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if member, ok := r.find(orgID, userID); ok {
+		delete(r.s.members, member.ID)
+	}
+
+	return nil
+}
+
+func (r *organizationMemberRepository) ListByUser(ctx context.Context, userID uuid.UUID) ([]models.OrganizationMember, error) {
+	return r.list(func(member models.OrganizationMember) bool { return member.UserID == userID }), nil
+}
+
+func (r *organizationMemberRepository) ListByOrganization(ctx context.Context, orgID uuid.UUID) ([]models.OrganizationMember, error) {
+	return r.list(func(member models.OrganizationMember) bool { return member.OrganizationID == orgID }), nil
+}
+
+// SetRole changes the role of a member, adding the membership when missing
+func (r *organizationMemberRepository) SetRole(ctx context.Context, orgID, userID uuid.UUID, role models.OrganizationRole) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	member, ok := r.find(orgID, userID)
+	if !ok {
+		r.insert(orgID, userID, role, "")
+		return nil
+	}
+
+	member.Role = role
+	member.UpdatedAt = r.s.Now()
+	r.s.members[member.ID] = member
+	return nil
+}
+
+func (r *organizationMemberRepository) ListMembers(ctx context.Context, orgID uuid.UUID, params listing.Params) (*listing.Page[models.OrganizationMember], error) {
+	members := r.list(func(member models.OrganizationMember) bool { return member.OrganizationID == orgID })
+
+	return page(members, params, columns[models.OrganizationMember]{
+		id: func(m models.OrganizationMember) uuid.UUID { return m.ID },
+		sorts: map[string]func(models.OrganizationMember) *string{
+			"role": func(m models.OrganizationMember) *string {
+				return rankKey(m.Role, models.RoleOwner, models.RoleAdmin, models.RoleMember)
+			},
+			"joined_at": func(m models.OrganizationMember) *string { return timeKey(m.JoinedAt) },
+		},
+		filters: map[string]func(models.OrganizationMember, []any) bool{
+			"role": func(m models.OrganizationMember, values []any) bool { return enumEqualsAny(m.Role, values) },
+		},
+	}), nil
+}
+
+// find looks up a membership, the caller holds the lock
+func (r *organizationMemberRepository) find(orgID, userID uuid.UUID) (models.OrganizationMember, bool) {
+	for _, member := range r.s.members {
+		if member.OrganizationID == orgID && member.UserID == userID {
+			return member, true
+		}
+	}
+	return models.OrganizationMember{}, false
+}
+
+// insert adds a membership, the caller holds the lock
+func (r *organizationMemberRepository) insert(orgID, userID uuid.UUID, role models.OrganizationRole, clerkMembershipID string) {
+	now := r.s.Now()
+	member := models.OrganizationMember{
+		ID:                uuid.New(),
+		OrganizationID:    orgID,
+		UserID:            userID,
+		Role:              role,
+		ClerkMembershipID: clerkMembershipID,
+		JoinedAt:          now,
+		UpdatedAt:         now,
+	}
+	r.s.members[member.ID] = member
+}
+
+// list returns the matching memberships in the order they were joined
+func (r *organizationMemberRepository) list(match func(models.OrganizationMember) bool) []models.OrganizationMember {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	members := make([]models.OrganizationMember, 0)
+	for _, member := range r.s.members {
+		if match(member) {
+			members = append(members, member)
+		}
+	}
+
+	slices.SortFunc(members, func(a, b models.OrganizationMember) int {
+		return a.JoinedAt.Compare(b.JoinedAt)
+	})
+
+	return members
+}
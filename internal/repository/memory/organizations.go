@@ -0,0 +1,224 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/atavada/project-management-saas/internal/apperror"
+	"github.com/atavada/project-management-saas/internal/listing"
+	"github.com/atavada/project-management-saas/internal/models"
+	"github.com/google/uuid"
+)
+
+// defaultSettings mirrors the column default of organizations.settings
+func defaultSettings() models.OrganizationSettings {
+	return models.OrganizationSettings{
+		DefaultTaskPriority: models.PriorityMedium,
+		WorkingDays:         []models.Weekday{models.Monday, models.Tuesday, models.Wednesday, models.Thursday, models.Friday},
+		Timezone:            "UTC",
+	}
+}
+
+type organizationRepository struct {
+	s *Store
+}
+
+func (r *organizationRepository) Create(ctx context.Context, org *models.CreateOrganizationRequest) (*models.Organization, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for _, existing := range r.s.orgs {
+		if existing.ClerkOrgID == org.ClerkOrgID || existing.Slug == org.Slug {
+			return nil, apperror.Conflict("Organization already exists")
+		}
+	}
+
+	created := r.insert(org)
+	return &created, nil
+}
+
+func (r *organizationRepository) GetByClerkID(ctx context.Context, clerkOrgID string) (*models.Organization, error) {
+	return r.find(func(org models.Organization) bool { return org.ClerkOrgID == clerkOrgID })
+}
+
+func (r *organizationRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Organization, error) {
+	return r.find(func(org models.Organization) bool { return org.ID == id })
+}
+
+func (r *organizationRepository) GetBySlug(ctx context.Context, slug string) (*models.Organization, error) {
+	return r.find(func(org models.Organization) bool { return org.Slug == slug })
+}
+
+func (r *organizationRepository) GetByPreviousSlug(ctx context.Context, slug string) (*models.Organization, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	org, ok := r.s.orgs[r.s.slugHistory[slug]]
+	if !ok {
+		return nil, apperror.NotFound("Organization not found")
+	}
+
+	return cloneOrganization(org), nil
+}
+
+func (r *organizationRepository) RecordSlugChange(ctx context.Context, orgID uuid.UUID, oldSlug string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	r.s.slugHistory[oldSlug] = orgID
+	return nil
+}
+
+// Upsert syncs the Clerk-owned fields only, like the Postgres repository
+func (r *organizationRepository) Upsert(ctx context.Context, org *models.CreateOrganizationRequest) (*models.Organization, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for _, existing := range r.s.orgs {
+		if existing.Slug == org.Slug && existing.ClerkOrgID != org.ClerkOrgID {
+			return nil, apperror.Conflict("Organization slug is already taken")
+		}
+	}
+
+	for id, existing := range r.s.orgs {
+		if existing.ClerkOrgID != org.ClerkOrgID {
+			continue
+		}
+		existing.Name = org.Name
+		existing.Slug = org.Slug
+		existing.LogoURL = org.LogoURL
+		existing.UpdatedAt = r.s.Now()
+		r.s.orgs[id] = existing
+		return cloneOrganization(existing), nil
+	}
+
+	created := r.insert(org)
+	return &created, nil
+}
+
+// Update saves the app-owned fields of an organization
+func (r *organizationRepository) Update(ctx context.Context, org *models.Organization) (*models.Organization, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	existing, ok := r.s.orgs[org.ID]
+	if !ok {
+		return nil, apperror.NotFound("Organization not found")
+	}
+
+	existing.Description = org.Description
+	existing.Settings = org.Settings
+	existing.Settings.WorkingDays = slices.Clone(org.Settings.WorkingDays)
+	existing.UpdatedAt = r.s.Now()
+	r.s.orgs[org.ID] = existing
+
+	return cloneOrganization(existing), nil
+}
+
+func (r *organizationRepository) GetUserOrganizations(ctx context.Context, userID uuid.UUID) ([]models.OrganizationWithRole, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	// Newest membership first
+	rows := r.userOrganizations(userID)
+	slices.SortFunc(rows, func(a, b userOrganization) int {
+		return b.joinedAt.Compare(a.joinedAt)
+	})
+
+	orgs := make([]models.OrganizationWithRole, len(rows))
+	for i, row := range rows {
+		orgs[i] = row.OrganizationWithRole
+	}
+
+	return orgs, nil
+}
+
+func (r *organizationRepository) ListForUser(ctx context.Context, userID uuid.UUID, params listing.Params) (*listing.Page[models.OrganizationWithRole], error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	result := page(r.userOrganizations(userID), params, columns[userOrganization]{
+		id: func(o userOrganization) uuid.UUID { return o.ID },
+		sorts: map[string]func(userOrganization) *string{
+			"name":       func(o userOrganization) *string { return text(o.Name) },
+			"slug":       func(o userOrganization) *string { return text(o.Slug) },
+			"created_at": func(o userOrganization) *string { return timeKey(o.CreatedAt) },
+			"joined_at":  func(o userOrganization) *string { return timeKey(o.joinedAt) },
+		},
+		filters: map[string]func(userOrganization, []any) bool{
+			"role": func(o userOrganization, values []any) bool { return enumEqualsAny(o.Role, values) },
+		},
+		search: func(o userOrganization) []string { return []string{o.Name, o.Slug} },
+	})
+
+	orgs := make([]models.OrganizationWithRole, len(result.Items))
+	for i, row := range result.Items {
+		orgs[i] = row.OrganizationWithRole
+	}
+
+	return &listing.Page[models.OrganizationWithRole]{Items: orgs, Total: result.Total, Next: result.Next, Prev: result.Prev}, nil
+}
+
+// userOrganization is a row of the organizations joined with memberships
+type userOrganization struct {
+	models.OrganizationWithRole
+	joinedAt time.Time
+}
+
+// userOrganizations joins the user's memberships with their organizations,
+// the caller holds the lock
+func (r *organizationRepository) userOrganizations(userID uuid.UUID) []userOrganization {
+	var rows []userOrganization
+	for _, member := range r.s.members {
+		if member.UserID != userID {
+			continue
+		}
+		org, ok := r.s.orgs[member.OrganizationID]
+		if !ok {
+			continue
+		}
+		rows = append(rows, userOrganization{
+			OrganizationWithRole: models.OrganizationWithRole{Organization: *cloneOrganization(org), Role: member.Role},
+			joinedAt:             member.JoinedAt,
+		})
+	}
+	return rows
+}
+
+func (r *organizationRepository) find(match func(models.Organization) bool) (*models.Organization, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	for _, org := range r.s.orgs {
+		if match(org) {
+			return cloneOrganization(org), nil
+		}
+	}
+
+	return nil, apperror.NotFound("Organization not found")
+}
+
+// insert adds a new organization, the caller holds the lock
+func (r *organizationRepository) insert(org *models.CreateOrganizationRequest) models.Organization {
+	now := r.s.Now()
+	created := models.Organization{
+		ID:          uuid.New(),
+		ClerkOrgID:  org.ClerkOrgID,
+		Name:        org.Name,
+		Slug:        org.Slug,
+		Description: org.Description,
+		LogoURL:     org.LogoURL,
+		Settings:    defaultSettings(),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	r.s.orgs[created.ID] = created
+	return *cloneOrganization(created)
+}
+
+// cloneOrganization copies org so callers can't change the stored working days
+func cloneOrganization(org models.Organization) *models.Organization {
+	org.Settings.WorkingDays = slices.Clone(org.Settings.WorkingDays)
+	return &org
+}
@@ -0,0 +1,40 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/atavada/project-management-saas/internal/models"
+	"github.com/google/uuid"
+)
+
+type userPreferencesRepository struct {
+	s *Store
+}
+
+// Get returns nil when the user has never saved preferences
+func (r *userPreferencesRepository) Get(ctx context.Context, userID uuid.UUID) (*models.UserPreferences, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	prefs, ok := r.s.prefs[userID]
+	if !ok {
+		return nil, nil
+	}
+
+	return &prefs, nil
+}
+
+func (r *userPreferencesRepository) Upsert(ctx context.Context, prefs *models.UserPreferences) (*models.UserPreferences, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	saved := *prefs
+	if prefs.DisplayName != nil {
+		displayName := *prefs.DisplayName
+		saved.DisplayName = &displayName
+	}
+	saved.UpdatedAt = r.s.Now()
+	r.s.prefs[prefs.UserID] = saved
+
+	return &saved, nil
+}
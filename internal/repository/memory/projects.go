@@ -0,0 +1,107 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/atavada/project-management-saas/internal/apperror"
+	"github.com/atavada/project-management-saas/internal/listing"
+	"github.com/atavada/project-management-saas/internal/models"
+	"github.com/google/uuid"
+)
+
+type projectRepository struct {
+	s *Store
+}
+
+func (r *projectRepository) Create(ctx context.Context, project *models.CreateProjectRequest) (*models.Project, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if _, ok := r.s.orgs[project.OrganizationID]; !ok {
+		return nil, fmt.Errorf("error creating project: organization %s does not exist", project.OrganizationID)
+	}
+
+	status := project.Status
+	if status == "" {
+		status = models.ProjectStatusActive
+	}
+
+	now := r.s.Now()
+	created := models.Project{
+		ID:             uuid.New(),
+		OrganizationID: project.OrganizationID,
+		Name:           project.Name,
+		Description:    project.Description,
+		Status:         status,
+		StartDate:      project.StartDate,
+		EndDate:        project.EndDate,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	r.s.projects[created.ID] = created
+
+	return &created, nil
+}
+
+func (r *projectRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Project, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	project, ok := r.s.projects[id]
+	if !ok {
+		return nil, apperror.NotFound("Project not found")
+	}
+
+	return &project, nil
+}
+
+func (r *projectRepository) ListByOrganization(ctx context.Context, orgID uuid.UUID) ([]models.Project, error) {
+	projects := r.list(orgID)
+	slices.SortFunc(projects, func(a, b models.Project) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+
+	return projects, nil
+}
+
+func (r *projectRepository) ListForOrganization(ctx context.Context, orgID uuid.UUID, params listing.Params) (*listing.Page[models.Project], error) {
+	status := func(p models.Project) *string {
+		return rankKey(p.Status, models.ProjectStatusActive, models.ProjectStatusArchived, models.ProjectStatusCompleted)
+	}
+
+	return page(r.list(orgID), params, columns[models.Project]{
+		id: func(p models.Project) uuid.UUID { return p.ID },
+		sorts: map[string]func(models.Project) *string{
+			"name":       func(p models.Project) *string { return text(p.Name) },
+			"status":     status,
+			"start_date": func(p models.Project) *string { return optionalTimeKey(p.StartDate) },
+			"end_date":   func(p models.Project) *string { return optionalTimeKey(p.EndDate) },
+			"created_at": func(p models.Project) *string { return timeKey(p.CreatedAt) },
+			"updated_at": func(p models.Project) *string { return timeKey(p.UpdatedAt) },
+		},
+		filters: map[string]func(models.Project, []any) bool{
+			"status":        func(p models.Project, values []any) bool { return enumEqualsAny(p.Status, values) },
+			"starts_after":  func(p models.Project, values []any) bool { return onOrAfter(p.StartDate, values) },
+			"starts_before": func(p models.Project, values []any) bool { return onOrBefore(p.StartDate, values) },
+			"ends_after":    func(p models.Project, values []any) bool { return onOrAfter(p.EndDate, values) },
+			"ends_before":   func(p models.Project, values []any) bool { return onOrBefore(p.EndDate, values) },
+		},
+		search: func(p models.Project) []string { return []string{p.Name} },
+	}), nil
+}
+
+func (r *projectRepository) list(orgID uuid.UUID) []models.Project {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	projects := make([]models.Project, 0)
+	for _, project := range r.s.projects {
+		if project.OrganizationID == orgID {
+			projects = append(projects, project)
+		}
+	}
+
+	return projects
+}
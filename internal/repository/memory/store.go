@@ -0,0 +1,143 @@
+// Package memory implements the repository interfaces in memory, for tests
+// that exercise handlers, webhook processing and authorization without
+// Postgres. The fakes keep the contracts of the Postgres repositories: the same
+// NotFound and Conflict errors, upsert semantics and cascades
+package memory
+
+import (
+	"context"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/atavada/project-management-saas/internal/database"
+	"github.com/atavada/project-management-saas/internal/models"
+	"github.com/atavada/project-management-saas/internal/repository"
+	"github.com/google/uuid"
+)
+
+// Store holds the tables shared by the fakes, so joins like an organization's
+// tasks or a user's organizations see what the other repositories wrote
+type Store struct {
+	mu sync.RWMutex
+
+	// Now stamps created and updated times, tests can replace it
+	Now func() time.Time
+
+	users        map[uuid.UUID]models.User
+	orgs         map[uuid.UUID]models.Organization
+	slugHistory  map[string]uuid.UUID
+	members      map[uuid.UUID]models.OrganizationMember
+	projects     map[uuid.UUID]models.Project
+	tasks        map[uuid.UUID]models.Task
+	prefs        map[uuid.UUID]models.UserPreferences
+	exports      map[uuid.UUID]dataExport
+	auditEvents  map[uuid.UUID]models.AuditEvent
+	taskActivity map[uuid.UUID]models.TaskActivity
+}
+
+var _ database.Transactor = (*Store)(nil)
+
+func NewStore() *Store {
+	return &Store{
+		Now:          func() time.Time { return time.Now().UTC() },
+		users:        make(map[uuid.UUID]models.User),
+		orgs:         make(map[uuid.UUID]models.Organization),
+		slugHistory:  make(map[string]uuid.UUID),
+		members:      make(map[uuid.UUID]models.OrganizationMember),
+		projects:     make(map[uuid.UUID]models.Project),
+		tasks:        make(map[uuid.UUID]models.Task),
+		prefs:        make(map[uuid.UUID]models.UserPreferences),
+		exports:      make(map[uuid.UUID]dataExport),
+		auditEvents:  make(map[uuid.UUID]models.AuditEvent),
+		taskActivity: make(map[uuid.UUID]models.TaskActivity),
+	}
+}
+
+func (s *Store) Users() repository.UserRepository {
+	return &userRepository{s: s}
+}
+
+func (s *Store) Organizations() repository.OrganizationRepository {
+	return &organizationRepository{s: s}
+}
+
+func (s *Store) Members() repository.OrganizationMemberRepository {
+	return &organizationMemberRepository{s: s}
+}
+
+func (s *Store) Projects() repository.ProjectRepository {
+	return &projectRepository{s: s}
+}
+
+func (s *Store) Tasks() repository.TaskRepository {
+	return &taskRepository{s: s}
+}
+
+func (s *Store) Preferences() repository.UserPreferencesRepository {
+	return &userPreferencesRepository{s: s}
+}
+
+func (s *Store) Exports() repository.DataExportRepository {
+	return &dataExportRepository{s: s}
+}
+
+func (s *Store) AuditEvents() repository.AuditEventRepository {
+	return &auditEventRepository{s: s}
+}
+
+func (s *Store) TaskActivity() repository.TaskActivityRepository {
+	return &taskActivityRepository{s: s}
+}
+
+type txKey struct{}
+
+// WithTx runs fn as a unit of work: when it fails every table is put back the
+// way it was. Writes made concurrently by other callers are rolled back too,
+// which is fine for tests that drive one operation at a time
+func (s *Store) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if ctx.Value(txKey{}) != nil {
+		return fn(ctx)
+	}
+
+	restore := s.snapshot()
+	if err := fn(context.WithValue(ctx, txKey{}, true)); err != nil {
+		restore()
+		return err
+	}
+
+	return nil
+}
+
+// snapshot copies the tables and returns a func that restores them
+func (s *Store) snapshot() func() {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	users := maps.Clone(s.users)
+	orgs := maps.Clone(s.orgs)
+	slugHistory := maps.Clone(s.slugHistory)
+	members := maps.Clone(s.members)
+	projects := maps.Clone(s.projects)
+	tasks := maps.Clone(s.tasks)
+	prefs := maps.Clone(s.prefs)
+	exports := maps.Clone(s.exports)
+	auditEvents := maps.Clone(s.auditEvents)
+	taskActivity := maps.Clone(s.taskActivity)
+
+	return func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		s.users = users
+		s.orgs = orgs
+		s.slugHistory = slugHistory
+		s.members = members
+		s.projects = projects
+		s.tasks = tasks
+		s.prefs = prefs
+		s.exports = exports
+		s.auditEvents = auditEvents
+		s.taskActivity = taskActivity
+	}
+}
@@ -0,0 +1,93 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/atavada/project-management-saas/internal/listing"
+	"github.com/atavada/project-management-saas/internal/models"
+	"github.com/google/uuid"
+)
+
+type taskActivityRepository struct {
+	s *Store
+}
+
+func (r *taskActivityRepository) Create(ctx context.Context, activity *models.TaskActivity) (*models.TaskActivity, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	created := *activity
+	created.ID = uuid.New()
+	created.Actor = nil
+	created.CreatedAt = r.s.Now()
+	r.s.taskActivity[created.ID] = created
+
+	created.Actor = r.actor(created.ActorID)
+	return &created, nil
+}
+
+func (r *taskActivityRepository) ListForTask(ctx context.Context, taskID uuid.UUID, params listing.Params) (*listing.Page[models.TaskActivity], error) {
+	r.s.mu.RLock()
+	entries := make([]models.TaskActivity, 0)
+	for _, activity := range r.s.taskActivity {
+		if activity.TaskID == taskID {
+			activity.Actor = r.actor(activity.ActorID)
+			entries = append(entries, activity)
+		}
+	}
+	r.s.mu.RUnlock()
+
+	return page(entries, params, columns[models.TaskActivity]{
+		id: func(a models.TaskActivity) uuid.UUID { return a.ID },
+		sorts: map[string]func(models.TaskActivity) *string{
+			"created_at": func(a models.TaskActivity) *string { return timeKey(a.CreatedAt) },
+		},
+		filters: map[string]func(models.TaskActivity, []any) bool{
+			"type":     func(a models.TaskActivity, values []any) bool { return enumEqualsAny(a.Type, values) },
+			"actor_id": func(a models.TaskActivity, values []any) bool { return optionalEqualsAny(a.ActorID, values) },
+		},
+	}), nil
+}
+
+func (r *taskActivityRepository) ListByActor(ctx context.Context, userID uuid.UUID) ([]models.TaskActivity, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	entries := make([]models.TaskActivity, 0)
+	for _, activity := range r.s.taskActivity {
+		if activity.ActorID != nil && *activity.ActorID == userID {
+			activity.Actor = r.actor(activity.ActorID)
+			entries = append(entries, activity)
+		}
+	}
+
+	slices.SortFunc(entries, func(a, b models.TaskActivity) int {
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID.String(), b.ID.String())
+	})
+
+	return entries, nil
+}
+
+// actor joins the user behind an entry, the caller holds the lock
+func (r *taskActivityRepository) actor(id *uuid.UUID) *models.TaskActivityActor {
+	if id == nil {
+		return nil
+	}
+
+	user, ok := r.s.users[*id]
+	if !ok {
+		return nil
+	}
+
+	return &models.TaskActivityActor{
+		ID:        user.ID,
+		FirstName: user.FirstName,
+		LastName:  user.LastName,
+		AvatarURL: user.AvatarURL,
+	}
+}
@@ -0,0 +1,179 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/atavada/project-management-saas/internal/apperror"
+	"github.com/atavada/project-management-saas/internal/listing"
+	"github.com/atavada/project-management-saas/internal/models"
+	"github.com/google/uuid"
+)
+
+type taskRepository struct {
+	s *Store
+}
+
+func (r *taskRepository) Create(ctx context.Context, task *models.CreateTaskRequest) (*models.Task, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if _, ok := r.s.projects[task.ProjectID]; !ok {
+		return nil, fmt.Errorf("error creating task: project %s does not exist", task.ProjectID)
+	}
+
+	status := task.Status
+	if status == "" {
+		status = models.TaskStatusTodo
+	}
+	priority := task.Priority
+	if priority == "" {
+		priority = models.PriorityMedium
+	}
+
+	now := r.s.Now()
+	created := models.Task{
+		ID:          uuid.New(),
+		ProjectID:   task.ProjectID,
+		AssignedTo:  task.AssignedTo,
+		CreatedBy:   task.CreatedBy,
+		Title:       task.Title,
+		Description: task.Description,
+		Status:      status,
+		Priority:    priority,
+		DueDate:     task.DueDate,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if status == models.TaskStatusDone {
+		created.CompletedAt = &now
+	}
+	r.s.tasks[created.ID] = created
+
+	return &created, nil
+}
+
+func (r *taskRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Task, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	task, ok := r.s.tasks[id]
+	if !ok {
+		return nil, apperror.NotFound("Task not found")
+	}
+
+	return &task, nil
+}
+
+// GetByIDForUpdate is GetByID, the store has no row locks. Like WithTx it
+// expects one operation at a time
+func (r *taskRepository) GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*models.Task, error) {
+	return r.GetByID(ctx, id)
+}
+
+func (r *taskRepository) Update(ctx context.Context, task *models.Task) (*models.Task, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	updated, ok := r.s.tasks[task.ID]
+	if !ok {
+		return nil, apperror.NotFound("Task not found")
+	}
+
+	now := r.s.Now()
+	switch {
+	case task.Status != models.TaskStatusDone:
+		updated.CompletedAt = nil
+	case updated.CompletedAt == nil:
+		updated.CompletedAt = &now
+	}
+	if !sameDate(updated.DueDate, task.DueDate) {
+		updated.ReminderSent = false
+	}
+
+	updated.Title = task.Title
+	updated.Description = task.Description
+	updated.Status = task.Status
+	updated.Priority = task.Priority
+	updated.AssignedTo = task.AssignedTo
+	updated.DueDate = task.DueDate
+	updated.UpdatedAt = now
+	r.s.tasks[updated.ID] = updated
+
+	return &updated, nil
+}
+
+func (r *taskRepository) ListByCreator(ctx context.Context, userID uuid.UUID) ([]models.Task, error) {
+	return r.list(func(task models.Task) bool { return task.CreatedBy == userID }), nil
+}
+
+func (r *taskRepository) ListByAssignee(ctx context.Context, userID uuid.UUID) ([]models.Task, error) {
+	return r.list(func(task models.Task) bool { return task.AssignedTo != nil && *task.AssignedTo == userID }), nil
+}
+
+func (r *taskRepository) ListForOrganization(ctx context.Context, orgID uuid.UUID, params listing.Params) (*listing.Page[models.Task], error) {
+	r.s.mu.RLock()
+	inOrg := func(task models.Task) bool { return r.s.projects[task.ProjectID].OrganizationID == orgID }
+	tasks := make([]models.Task, 0)
+	for _, task := range r.s.tasks {
+		if inOrg(task) {
+			tasks = append(tasks, task)
+		}
+	}
+	r.s.mu.RUnlock()
+
+	return page(tasks, params, columns[models.Task]{
+		id: func(t models.Task) uuid.UUID { return t.ID },
+		sorts: map[string]func(models.Task) *string{
+			"title": func(t models.Task) *string { return text(t.Title) },
+			"status": func(t models.Task) *string {
+				return rankKey(t.Status, models.TaskStatusTodo, models.TaskStatusInProgress, models.TaskStatusDone)
+			},
+			"priority": func(t models.Task) *string {
+				return rankKey(t.Priority, models.PriorityLow, models.PriorityMedium, models.PriorityHigh)
+			},
+			"due_date":   func(t models.Task) *string { return optionalTimeKey(t.DueDate) },
+			"created_at": func(t models.Task) *string { return timeKey(t.CreatedAt) },
+			"updated_at": func(t models.Task) *string { return timeKey(t.UpdatedAt) },
+		},
+		filters: map[string]func(models.Task, []any) bool{
+			"project_id":  func(t models.Task, values []any) bool { return equalsAny(t.ProjectID, values) },
+			"assigned_to": func(t models.Task, values []any) bool { return optionalEqualsAny(t.AssignedTo, values) },
+			"created_by":  func(t models.Task, values []any) bool { return equalsAny(t.CreatedBy, values) },
+			"status":      func(t models.Task, values []any) bool { return enumEqualsAny(t.Status, values) },
+			"priority":    func(t models.Task, values []any) bool { return enumEqualsAny(t.Priority, values) },
+			"due_after":   func(t models.Task, values []any) bool { return onOrAfter(t.DueDate, values) },
+			"due_before":  func(t models.Task, values []any) bool { return onOrBefore(t.DueDate, values) },
+		},
+		search: func(t models.Task) []string { return []string{t.Title} },
+	}), nil
+}
+
+// list returns the matching tasks in the order they were created
+func (r *taskRepository) list(match func(models.Task) bool) []models.Task {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	tasks := make([]models.Task, 0)
+	for _, task := range r.s.tasks {
+		if match(task) {
+			tasks = append(tasks, task)
+		}
+	}
+
+	slices.SortFunc(tasks, func(a, b models.Task) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+
+	return tasks
+}
+
+// sameDate compares due dates the way the DATE column stores them
+func sameDate(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Format(time.DateOnly) == b.Format(time.DateOnly)
+}
@@ -0,0 +1,142 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/atavada/project-management-saas/internal/apperror"
+	"github.com/atavada/project-management-saas/internal/models"
+	"github.com/google/uuid"
+)
+
+type userRepository struct {
+	s *Store
+}
+
+func (r *userRepository) Create(ctx context.Context, user *models.CreateUserRequest) (*models.User, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for _, existing := range r.s.users {
+		if existing.ClerkUserID == user.ClerkUserID || existing.Email == user.Email {
+			return nil, apperror.Conflict("User already exists")
+		}
+	}
+
+	now := r.s.Now()
+	created := models.User{
+		ID:          uuid.New(),
+		ClerkUserID: user.ClerkUserID,
+		Email:       user.Email,
+		FirstName:   user.FirstName,
+		LastName:    user.LastName,
+		AvatarURL:   user.AvatarURL,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	r.s.users[created.ID] = created
+
+	return &created, nil
+}
+
+func (r *userRepository) GetByClerkID(ctx context.Context, clerkUserID string) (*models.User, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	for _, user := range r.s.users {
+		if user.ClerkUserID == clerkUserID {
+			return &user, nil
+		}
+	}
+
+	return nil, apperror.NotFound("User not found")
+}
+
+func (r *userRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	user, ok := r.s.users[id]
+	if !ok {
+		return nil, apperror.NotFound("User not found")
+	}
+
+	return &user, nil
+}
+
+func (r *userRepository) Upsert(ctx context.Context, user *models.CreateUserRequest) (*models.User, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for id, existing := range r.s.users {
+		if existing.ClerkUserID != user.ClerkUserID {
+			continue
+		}
+		existing.Email = user.Email
+		existing.FirstName = user.FirstName
+		existing.LastName = user.LastName
+		existing.AvatarURL = user.AvatarURL
+		existing.UpdatedAt = r.s.Now()
+		r.s.users[id] = existing
+		return &existing, nil
+	}
+
+	now := r.s.Now()
+	created := models.User{
+		ID:          uuid.New(),
+		ClerkUserID: user.ClerkUserID,
+		Email:       user.Email,
+		FirstName:   user.FirstName,
+		LastName:    user.LastName,
+		AvatarURL:   user.AvatarURL,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	r.s.users[created.ID] = created
+
+	return &created, nil
+}
+
+// Anonymize erases the personal data like the Postgres repository does and
+// removes the user's preferences, exports and memberships
+func (r *userRepository) Anonymize(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	user, ok := r.s.users[id]
+	if !ok {
+		return nil, apperror.NotFound("User not found")
+	}
+
+	for eventID, event := range r.s.auditEvents {
+		if event.ActorType != models.AuditActorUser {
+			continue
+		}
+		if (event.ActorID != nil && *event.ActorID == id) || event.ActorName == user.ClerkUserID {
+			event.ActorName = ""
+			event.IPAddress = nil
+			r.s.auditEvents[eventID] = event
+		}
+	}
+
+	user.ClerkUserID = "deleted_" + id.String()
+	user.Email = id.String() + "@deleted.invalid"
+	user.FirstName = "Deleted"
+	user.LastName = "User"
+	user.AvatarURL = ""
+	user.UpdatedAt = r.s.Now()
+	r.s.users[id] = user
+
+	delete(r.s.prefs, id)
+	for exportID, export := range r.s.exports {
+		if export.UserID == id {
+			delete(r.s.exports, exportID)
+		}
+	}
+	for memberID, member := range r.s.members {
+		if member.UserID == id {
+			delete(r.s.members, memberID)
+		}
+	}
+
+	return &user, nil
+}
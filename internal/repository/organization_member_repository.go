@@ -2,30 +2,68 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
+	"github.com/atavada/project-management-saas/internal/apperror"
 	"github.com/atavada/project-management-saas/internal/database"
+	"github.com/atavada/project-management-saas/internal/listing"
 	"github.com/atavada/project-management-saas/internal/models"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 )
 
-type OrganizationMemberRepository struct {
+const memberColumns = `id, organization_id, user_id, role, COALESCE(clerk_membership_id, ''), joined_at, updated_at`
+
+// MemberListSpec is what the members of an organization can be listed by
+var MemberListSpec = listing.Spec{
+	Sorts: map[string]string{
+		"role":      "role",
+		"joined_at": "joined_at",
+	},
+	DefaultSort: []listing.Sort{{Field: "joined_at"}},
+	Tiebreak:    "id",
+	Filters: map[string]listing.Filter{
+		"role": {
+			Column: "role",
+			Op:     listing.Eq,
+			Type:   listing.Enum,
+			Values: []string{string(models.RoleOwner), string(models.RoleAdmin), string(models.RoleMember)},
+			Cast:   "organization_role",
+			Multi:  true,
+		},
+	},
+}
+
+// OrganizationMemberRepository stores who belongs to which organization and
+// with what role
+type OrganizationMemberRepository interface {
+	Create(ctx context.Context, member *models.OrganizationMember) error
+	Upsert(ctx context.Context, member *models.OrganizationMember) error
+	GetMember(ctx context.Context, orgID, userID uuid.UUID) (*models.OrganizationMember, error)
+	Delete(ctx context.Context, orgID, userID uuid.UUID) error
+	ListByUser(ctx context.Context, userID uuid.UUID) ([]models.OrganizationMember, error)
+	ListByOrganization(ctx context.Context, orgID uuid.UUID) ([]models.OrganizationMember, error)
+	SetRole(ctx context.Context, orgID, userID uuid.UUID, role models.OrganizationRole) error
+	ListMembers(ctx context.Context, orgID uuid.UUID, params listing.Params) (*listing.Page[models.OrganizationMember], error)
+}
+
+type organizationMemberRepository struct {
     db *database.DB
 }
 
-func NewOrganizationMemberRepository(db *database.DB) *OrganizationMemberRepository {
-    return &OrganizationMemberRepository{db: db}
+func NewOrganizationMemberRepository(db *database.DB) OrganizationMemberRepository {
+    return &organizationMemberRepository{db: db}
 }
 
-func (r *OrganizationMemberRepository) Create(ctx context.Context, member *models.OrganizationMember) error {
+func (r *organizationMemberRepository) Create(ctx context.Context, member *models.OrganizationMember) error {
     query := `
         INSERT INTO organization_members (organization_id, user_id, role, clerk_membership_id)
-        VALUES ($1, $2, $3, $4)
+        VALUES ($1, $2, $3, NULLIF($4, ''))
         ON CONFLICT (organization_id, user_id) DO NOTHING
     `
 
-    _, err := r.db.Pool.Exec(
+    _, err := r.db.Conn(ctx).Exec(
         ctx,
         query,
         member.OrganizationID,
@@ -41,15 +79,42 @@ func (r *OrganizationMemberRepository) Create(ctx context.Context, member *model
     return nil
 }
 
-func (r *OrganizationMemberRepository) GetMember(ctx context.Context, orgID, userID uuid.UUID) (*models.OrganizationMember, error) {
+// Upsert adds a membership or updates the role and Clerk membership ID of an
+// existing one. Owners keep their role, Clerk has no owner role
+func (r *organizationMemberRepository) Upsert(ctx context.Context, member *models.OrganizationMember) error {
+    query := `
+        INSERT INTO organization_members (organization_id, user_id, role, clerk_membership_id)
+        VALUES ($1, $2, $3, NULLIF($4, ''))
+        ON CONFLICT (organization_id, user_id) DO UPDATE
+        SET role = CASE WHEN organization_members.role = 'owner' THEN organization_members.role ELSE EXCLUDED.role END,
+            clerk_membership_id = COALESCE(EXCLUDED.clerk_membership_id, organization_members.clerk_membership_id)
+    `
+
+    _, err := r.db.Conn(ctx).Exec(
+        ctx,
+        query,
+        member.OrganizationID,
+        member.UserID,
+        member.Role,
+        member.ClerkMembershipID,
+    )
+
+    if err != nil {
+        return fmt.Errorf("error upserting organization member: %w", err)
+    }
+
+    return nil
+}
+
+func (r *organizationMemberRepository) GetMember(ctx context.Context, orgID, userID uuid.UUID) (*models.OrganizationMember, error) {
     query := `
-        SELECT id, organization_id, user_id, role, clerk_membership_id, joined_at, updated_at
+        SELECT id, organization_id, user_id, role, COALESCE(clerk_membership_id, ''), joined_at, updated_at
         FROM organization_members
         WHERE organization_id = $1 AND user_id = $2
     `
 
     var member models.OrganizationMember
-    err := r.db.Pool.QueryRow(ctx, query, orgID, userID).Scan(
+    err := r.db.Conn(ctx).QueryRow(ctx, query, orgID, userID).Scan(
         &member.ID,
         &member.OrganizationID,
         &member.UserID,
@@ -59,8 +124,8 @@ func (r *OrganizationMemberRepository) GetMember(ctx context.Context, orgID, use
         &member.UpdatedAt,
     )
 
-    if err == pgx.ErrNoRows {
-        return nil, nil
+    if errors.Is(err, pgx.ErrNoRows) {
+        return nil, apperror.NotFound("Membership not found")
     }
     if err != nil {
         return nil, fmt.Errorf("error getting member: %w", err)
@@ -69,16 +134,138 @@ func (r *OrganizationMemberRepository) GetMember(ctx context.Context, orgID, use
     return &member, nil
 }
 
-func (r *OrganizationMemberRepository) Delete(ctx context.Context, orgID, userID uuid.UUID) error {
+func (r *organizationMemberRepository) Delete(ctx context.Context, orgID, userID uuid.UUID) error {
     query := `
         DELETE FROM organization_members
         WHERE organization_id = $1 AND user_id = $2
     `
 
-    _, err := r.db.Pool.Exec(ctx, query, orgID, userID)
+    _, err := r.db.Conn(ctx).Exec(ctx, query, orgID, userID)
     if err != nil {
         return fmt.Errorf("error deleting member: %w", err)
     }
 
     return nil
-}
\ No newline at end of file
+}
+
+// ListByUser returns all memberships of a user
+func (r *organizationMemberRepository) ListByUser(ctx context.Context, userID uuid.UUID) ([]models.OrganizationMember, error) {
+    query := `
+        SELECT id, organization_id, user_id, role, COALESCE(clerk_membership_id, ''), joined_at, updated_at
+        FROM organization_members
+        WHERE user_id = $1
+        ORDER BY joined_at
+    `
+
+    rows, err := r.db.ReadConn(ctx).Query(ctx, query, userID)
+    if err != nil {
+        return nil, fmt.Errorf("error listing memberships: %w", err)
+    }
+    defer rows.Close()
+
+    var members []models.OrganizationMember
+    for rows.Next() {
+        var member models.OrganizationMember
+        err := rows.Scan(
+            &member.ID,
+            &member.OrganizationID,
+            &member.UserID,
+            &member.Role,
+            &member.ClerkMembershipID,
+            &member.JoinedAt,
+            &member.UpdatedAt,
+        )
+        if err != nil {
+            return nil, fmt.Errorf("error scanning membership: %w", err)
+        }
+        members = append(members, member)
+    }
+
+    if err := rows.Err(); err != nil {
+        return nil, fmt.Errorf("error iterating memberships: %w", err)
+    }
+
+    return members, nil
+}
+
+// ListByOrganization returns all memberships of an organization
+func (r *organizationMemberRepository) ListByOrganization(ctx context.Context, orgID uuid.UUID) ([]models.OrganizationMember, error) {
+    query := `
+        SELECT id, organization_id, user_id, role, COALESCE(clerk_membership_id, ''), joined_at, updated_at
+        FROM organization_members
+        WHERE organization_id = $1
+        ORDER BY joined_at
+    `
+
+    rows, err := r.db.Conn(ctx).Query(ctx, query, orgID)
+    if err != nil {
+        return nil, fmt.Errorf("error listing memberships: %w", err)
+    }
+    defer rows.Close()
+
+    var members []models.OrganizationMember
+    for rows.Next() {
+        var member models.OrganizationMember
+        err := rows.Scan(
+            &member.ID,
+            &member.OrganizationID,
+            &member.UserID,
+            &member.Role,
+            &member.ClerkMembershipID,
+            &member.JoinedAt,
+            &member.UpdatedAt,
+        )
+        if err != nil {
+            return nil, fmt.Errorf("error scanning membership: %w", err)
+        }
+        members = append(members, member)
+    }
+
+    if err := rows.Err(); err != nil {
+        return nil, fmt.Errorf("error iterating memberships: %w", err)
+    }
+
+    return members, nil
+}
+
+// SetRole changes the role of a member, adding the membership when missing
+func (r *organizationMemberRepository) SetRole(ctx context.Context, orgID, userID uuid.UUID, role models.OrganizationRole) error {
+    query := `
+        INSERT INTO organization_members (organization_id, user_id, role)
+        VALUES ($1, $2, $3)
+        ON CONFLICT (organization_id, user_id)
+        DO UPDATE SET role = EXCLUDED.role
+    `
+
+    _, err := r.db.Conn(ctx).Exec(ctx, query, orgID, userID, role)
+    if err != nil {
+        return fmt.Errorf("error setting member role: %w", err)
+    }
+
+    return nil
+}
+
+// ListMembers returns a page of the memberships of an organization
+func (r *organizationMemberRepository) ListMembers(ctx context.Context, orgID uuid.UUID, params listing.Params) (*listing.Page[models.OrganizationMember], error) {
+	q := newListQuery("organization_members")
+	q.where("organization_id = " + q.arg(orgID))
+	q.apply(MemberListSpec, params)
+
+	page, err := list(ctx, r.db.ReadConn(ctx), q, memberColumns, MemberListSpec, params,
+		func(row pgx.Row, member *models.OrganizationMember) error {
+			return row.Scan(
+				&member.ID,
+				&member.OrganizationID,
+				&member.UserID,
+				&member.Role,
+				&member.ClerkMembershipID,
+				&member.JoinedAt,
+				&member.UpdatedAt,
+			)
+		})
+	if err != nil {
+		return nil, fmt.Errorf("error listing members: %w", err)
+	}
+
+	return page, nil
+}
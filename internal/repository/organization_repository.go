@@ -2,31 +2,73 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
+	"github.com/atavada/project-management-saas/internal/apperror"
 	"github.com/atavada/project-management-saas/internal/database"
+	"github.com/atavada/project-management-saas/internal/listing"
 	"github.com/atavada/project-management-saas/internal/models"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 )
 
-type OrganizationRepository struct {
+// OrganizationListSpec is what the organizations of a user can be listed by
+var OrganizationListSpec = listing.Spec{
+	Sorts: map[string]string{
+		"name":       "o.name",
+		"slug":       "o.slug",
+		"created_at": "o.created_at",
+		"joined_at":  "om.joined_at",
+	},
+	DefaultSort: []listing.Sort{{Field: "joined_at", Desc: true}},
+	Tiebreak:    "o.id",
+	Filters: map[string]listing.Filter{
+		"role": {
+			Column: "om.role",
+			Op:     listing.Eq,
+			Type:   listing.Enum,
+			Values: []string{string(models.RoleOwner), string(models.RoleAdmin), string(models.RoleMember)},
+			Cast:   "organization_role",
+			Multi:  true,
+		},
+	},
+	Search: []string{"o.name", "o.slug"},
+}
+
+// OrganizationRepository stores organizations and the slugs they were renamed
+// away from. Lookups of missing organizations fail with an apperror NotFound
+// error
+type OrganizationRepository interface {
+	Create(ctx context.Context, org *models.CreateOrganizationRequest) (*models.Organization, error)
+	GetByClerkID(ctx context.Context, clerkOrgID string) (*models.Organization, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Organization, error)
+	GetBySlug(ctx context.Context, slug string) (*models.Organization, error)
+	GetByPreviousSlug(ctx context.Context, slug string) (*models.Organization, error)
+	RecordSlugChange(ctx context.Context, orgID uuid.UUID, oldSlug string) error
+	Upsert(ctx context.Context, org *models.CreateOrganizationRequest) (*models.Organization, error)
+	Update(ctx context.Context, org *models.Organization) (*models.Organization, error)
+	GetUserOrganizations(ctx context.Context, userID uuid.UUID) ([]models.OrganizationWithRole, error)
+	ListForUser(ctx context.Context, userID uuid.UUID, params listing.Params) (*listing.Page[models.OrganizationWithRole], error)
+}
+
+type organizationRepository struct {
 	db *database.DB
 }
 
-func NewOrganizationRepository(db *database.DB) *OrganizationRepository {
-	return &OrganizationRepository{db: db}
+func NewOrganizationRepository(db *database.DB) OrganizationRepository {
+	return &organizationRepository{db: db}
 }
 
-func (r *OrganizationRepository) Create(ctx context.Context, org *models.CreateOrganizationRequest) (*models.Organization, error) {
+func (r *organizationRepository) Create(ctx context.Context, org *models.CreateOrganizationRequest) (*models.Organization, error) {
 	query := `
 		INSERT INTO organizations (clerk_org_id, name, slug, description, logo_url)
 		VALUES ($1, $2, $3, $4, $5)
-		RETURNING id, clerk_org_id, name, slug, description, logo_url, created_at, updated_at
+		RETURNING id, clerk_org_id, name, slug, description, logo_url, settings, created_at, updated_at
 	`
 
 	var result models.Organization
-	err := r.db.Pool.QueryRow(
+	err := r.db.Conn(ctx).QueryRow(
 		ctx,
 		query,
 		org.ClerkOrgID,
@@ -41,10 +83,14 @@ func (r *OrganizationRepository) Create(ctx context.Context, org *models.CreateO
 		&result.Slug,
 		&result.Description,
 		&result.LogoURL,
+		&result.Settings,
 		&result.CreatedAt,
 		&result.UpdatedAt,
 	)
 
+	if isUniqueViolation(err) {
+		return nil, apperror.Conflict("Organization already exists").Wrap(err)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("error creating organization: %w", err)
 	}
@@ -52,27 +98,28 @@ func (r *OrganizationRepository) Create(ctx context.Context, org *models.CreateO
 	return &result, nil
 }
 
-func (r *OrganizationRepository) GetByClerkID(ctx context.Context, clerkOrgID string) (*models.Organization, error) {
+func (r *organizationRepository) GetByClerkID(ctx context.Context, clerkOrgID string) (*models.Organization, error) {
     query := `
-        SELECT id, clerk_org_id, name, slug, description, logo_url, created_at, updated_at
+        SELECT id, clerk_org_id, name, slug, description, logo_url, settings, created_at, updated_at
         FROM organizations
         WHERE clerk_org_id = $1
     `
 
     var org models.Organization
-    err := r.db.Pool.QueryRow(ctx, query, clerkOrgID).Scan(
+    err := r.db.Conn(ctx).QueryRow(ctx, query, clerkOrgID).Scan(
         &org.ID,
         &org.ClerkOrgID,
         &org.Name,
         &org.Slug,
         &org.Description,
         &org.LogoURL,
+        &org.Settings,
         &org.CreatedAt,
         &org.UpdatedAt,
     )
 
-    if err == pgx.ErrNoRows {
-        return nil, nil
+    if errors.Is(err, pgx.ErrNoRows) {
+        return nil, apperror.NotFound("Organization not found")
     }
     if err != nil {
         return nil, fmt.Errorf("error getting organization: %w", err)
@@ -81,27 +128,28 @@ func (r *OrganizationRepository) GetByClerkID(ctx context.Context, clerkOrgID st
     return &org, nil
 }
 
-func (r *OrganizationRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Organization, error) {
+func (r *organizationRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Organization, error) {
     query := `
-        SELECT id, clerk_org_id, name, slug, description, logo_url, created_at, updated_at
+        SELECT id, clerk_org_id, name, slug, description, logo_url, settings, created_at, updated_at
         FROM organizations
         WHERE id = $1
     `
 
     var org models.Organization
-    err := r.db.Pool.QueryRow(ctx, query, id).Scan(
+    err := r.db.Conn(ctx).QueryRow(ctx, query, id).Scan(
         &org.ID,
         &org.ClerkOrgID,
         &org.Name,
         &org.Slug,
         &org.Description,
         &org.LogoURL,
+        &org.Settings,
         &org.CreatedAt,
         &org.UpdatedAt,
     )
 
-    if err == pgx.ErrNoRows {
-        return nil, nil
+    if errors.Is(err, pgx.ErrNoRows) {
+        return nil, apperror.NotFound("Organization not found")
     }
     if err != nil {
         return nil, fmt.Errorf("error getting organization: %w", err)
@@ -110,7 +158,91 @@ func (r *OrganizationRepository) GetByID(ctx context.Context, id uuid.UUID) (*mo
     return &org, nil
 }
 
-func (r *OrganizationRepository) Upsert(ctx context.Context, org *models.CreateOrganizationRequest) (*models.Organization, error) {
+func (r *organizationRepository) GetBySlug(ctx context.Context, slug string) (*models.Organization, error) {
+    query := `
+        SELECT id, clerk_org_id, name, slug, description, logo_url, settings, created_at, updated_at
+        FROM organizations
+        WHERE slug = $1
+    `
+
+    var org models.Organization
+    err := r.db.Conn(ctx).QueryRow(ctx, query, slug).Scan(
+        &org.ID,
+        &org.ClerkOrgID,
+        &org.Name,
+        &org.Slug,
+        &org.Description,
+        &org.LogoURL,
+        &org.Settings,
+        &org.CreatedAt,
+        &org.UpdatedAt,
+    )
+
+    if errors.Is(err, pgx.ErrNoRows) {
+        return nil, apperror.NotFound("Organization not found")
+    }
+    if err != nil {
+        return nil, fmt.Errorf("error getting organization: %w", err)
+    }
+
+    return &org, nil
+}
+
+// GetByPreviousSlug returns the organization that used to be reachable under slug
+func (r *organizationRepository) GetByPreviousSlug(ctx context.Context, slug string) (*models.Organization, error) {
+    query := `
+        SELECT o.id, o.clerk_org_id, o.name, o.slug, o.description, o.logo_url, o.settings, o.created_at, o.updated_at
+        FROM organization_slug_history h
+        INNER JOIN organizations o ON o.id = h.organization_id
+        WHERE h.slug = $1
+    `
+
+    var org models.Organization
+    err := r.db.Conn(ctx).QueryRow(ctx, query, slug).Scan(
+        &org.ID,
+        &org.ClerkOrgID,
+        &org.Name,
+        &org.Slug,
+        &org.Description,
+        &org.LogoURL,
+        &org.Settings,
+        &org.CreatedAt,
+        &org.UpdatedAt,
+    )
+
+    if errors.Is(err, pgx.ErrNoRows) {
+        return nil, apperror.NotFound("Organization not found")
+    }
+    if err != nil {
+        return nil, fmt.Errorf("error getting organization by previous slug: %w", err)
+    }
+
+    return &org, nil
+}
+
+// RecordSlugChange remembers a slug an organization was renamed away from. A
+// slug can only point at one organization, the most recent owner wins
+func (r *organizationRepository) RecordSlugChange(ctx context.Context, orgID uuid.UUID, oldSlug string) error {
+    query := `
+        INSERT INTO organization_slug_history (organization_id, slug)
+        VALUES ($1, $2)
+        ON CONFLICT (slug)
+        DO UPDATE SET
+            organization_id = EXCLUDED.organization_id,
+            created_at = CURRENT_TIMESTAMP
+    `
+
+    _, err := r.db.Conn(ctx).Exec(ctx, query, orgID, oldSlug)
+    if err != nil {
+        return fmt.Errorf("error recording slug change: %w", err)
+    }
+
+    return nil
+}
+
+// Upsert syncs Clerk-owned fields only, description and settings are owned by
+// this app and are left untouched on conflict
+func (r *organizationRepository) Upsert(ctx context.Context, org *models.CreateOrganizationRequest) (*models.Organization, error) {
     query := `
         INSERT INTO organizations (clerk_org_id, name, slug, description, logo_url)
         VALUES ($1, $2, $3, $4, $5)
@@ -118,14 +250,13 @@ func (r *OrganizationRepository) Upsert(ctx context.Context, org *models.CreateO
         DO UPDATE SET
             name = EXCLUDED.name,
             slug = EXCLUDED.slug,
-            description = EXCLUDED.description,
             logo_url = EXCLUDED.logo_url,
             updated_at = CURRENT_TIMESTAMP
-        RETURNING id, clerk_org_id, name, slug, description, logo_url, created_at, updated_at
+        RETURNING id, clerk_org_id, name, slug, description, logo_url, settings, created_at, updated_at
     `
 
     var result models.Organization
-    err := r.db.Pool.QueryRow(
+    err := r.db.Conn(ctx).QueryRow(
         ctx,
         query,
         org.ClerkOrgID,
@@ -140,10 +271,15 @@ func (r *OrganizationRepository) Upsert(ctx context.Context, org *models.CreateO
         &result.Slug,
         &result.Description,
         &result.LogoURL,
+        &result.Settings,
         &result.CreatedAt,
         &result.UpdatedAt,
     )
 
+    // The clerk_org_id conflict is handled above, this is the slug
+    if isUniqueViolation(err) {
+        return nil, apperror.Conflict("Organization slug is already taken").Wrap(err)
+    }
     if err != nil {
         return nil, fmt.Errorf("error upserting organization: %w", err)
     }
@@ -151,10 +287,48 @@ func (r *OrganizationRepository) Upsert(ctx context.Context, org *models.CreateO
     return &result, nil
 }
 
-func (r *OrganizationRepository) GetUserOrganizations(ctx context.Context, userID uuid.UUID) ([]models.OrganizationWithRole, error) {
+// Update saves the app-owned fields of an organization
+func (r *organizationRepository) Update(ctx context.Context, org *models.Organization) (*models.Organization, error) {
+    query := `
+        UPDATE organizations
+        SET description = $2, settings = $3
+        WHERE id = $1
+        RETURNING id, clerk_org_id, name, slug, description, logo_url, settings, created_at, updated_at
+    `
+
+    var result models.Organization
+    err := r.db.Conn(ctx).QueryRow(
+        ctx,
+        query,
+        org.ID,
+        org.Description,
+        org.Settings,
+    ).Scan(
+        &result.ID,
+        &result.ClerkOrgID,
+        &result.Name,
+        &result.Slug,
+        &result.Description,
+        &result.LogoURL,
+        &result.Settings,
+        &result.CreatedAt,
+        &result.UpdatedAt,
+    )
+
+    if errors.Is(err, pgx.ErrNoRows) {
+        return nil, apperror.NotFound("Organization not found")
+    }
+    if err != nil {
+        return nil, fmt.Errorf("error updating organization: %w", err)
+    }
+
+    return &result, nil
+}
+
+func (r *organizationRepository) GetUserOrganizations(ctx context.Context, userID uuid.UUID) ([]models.OrganizationWithRole, error) {
     query := `
         SELECT 
-            o.id, o.clerk_org_id, o.name, o.slug, o.description, o.logo_url, 
+            o.id, o.clerk_org_id, o.name, o.slug, o.description, o.logo_url, o.settings,
             o.created_at, o.updated_at, om.role
         FROM organizations o
         INNER JOIN organization_members om ON o.id = om.organization_id
@@ -162,7 +336,7 @@ func (r *OrganizationRepository) GetUserOrganizations(ctx context.Context, userI
         ORDER BY om.joined_at DESC
     `
 
-    rows, err := r.db.Pool.Query(ctx, query, userID)
+    rows, err := r.db.ReadConn(ctx).Query(ctx, query, userID)
     if err != nil {
         return nil, fmt.Errorf("error getting user organizations: %w", err)
     }
@@ -178,6 +352,7 @@ func (r *OrganizationRepository) GetUserOrganizations(ctx context.Context, userI
             &org.Slug,
             &org.Description,
             &org.LogoURL,
+            &org.Settings,
             &org.CreatedAt,
             &org.UpdatedAt,
             &org.Role,
@@ -193,4 +368,36 @@ func (r *OrganizationRepository) GetUserOrganizations(ctx context.Context, userI
     }
 
     return organizations, nil
-}
\ No newline at end of file
+}
+
+// ListForUser returns a page of the organizations the user is a member of,
+// with their role in each
+func (r *organizationRepository) ListForUser(ctx context.Context, userID uuid.UUID, params listing.Params) (*listing.Page[models.OrganizationWithRole], error) {
+	q := newListQuery(`organizations o INNER JOIN organization_members om ON o.id = om.organization_id`)
+	q.where("om.user_id = " + q.arg(userID))
+	q.apply(OrganizationListSpec, params)
+
+	columns := `o.id, o.clerk_org_id, o.name, o.slug, o.description, o.logo_url, o.settings,
+		o.created_at, o.updated_at, om.role`
+
+	page, err := list(ctx, r.db.ReadConn(ctx), q, columns, OrganizationListSpec, params,
+		func(row pgx.Row, org *models.OrganizationWithRole) error {
+			return row.Scan(
+				&org.ID,
+				&org.ClerkOrgID,
+				&org.Name,
+				&org.Slug,
+				&org.Description,
+				&org.LogoURL,
+				&org.Settings,
+				&org.CreatedAt,
+				&org.UpdatedAt,
+				&org.Role,
+			)
+		})
+	if err != nil {
+		return nil, fmt.Errorf("error listing user organizations: %w", err)
+	}
+
+	return page, nil
+}
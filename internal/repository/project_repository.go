@@ -0,0 +1,160 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/atavada/project-management-saas/internal/apperror"
+	"github.com/atavada/project-management-saas/internal/database"
+	"github.com/atavada/project-management-saas/internal/listing"
+	"github.com/atavada/project-management-saas/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const projectColumns = `id, organization_id, name, COALESCE(description, ''), status, start_date, end_date, created_at, updated_at`
+
+// ProjectListSpec is what the projects of an organization can be listed by
+var ProjectListSpec = listing.Spec{
+	Sorts: map[string]string{
+		"name":       "name",
+		"status":     "status",
+		"start_date": "start_date",
+		"end_date":   "end_date",
+		"created_at": "created_at",
+		"updated_at": "updated_at",
+	},
+	DefaultSort: []listing.Sort{{Field: "created_at"}},
+	Tiebreak:    "id",
+	Filters: map[string]listing.Filter{
+		"status": {
+			Column: "status",
+			Op:     listing.Eq,
+			Type:   listing.Enum,
+			Values: []string{string(models.ProjectStatusActive), string(models.ProjectStatusArchived), string(models.ProjectStatusCompleted)},
+			Cast:   "project_status",
+			Multi:  true,
+		},
+		"starts_after":  {Column: "start_date", Op: listing.Gte, Type: listing.Date},
+		"starts_before": {Column: "start_date", Op: listing.Lte, Type: listing.Date},
+		"ends_after":    {Column: "end_date", Op: listing.Gte, Type: listing.Date},
+		"ends_before":   {Column: "end_date", Op: listing.Lte, Type: listing.Date},
+	},
+	Search: []string{"name"},
+}
+
+// ProjectRepository stores the projects of organizations
+type ProjectRepository interface {
+	Create(ctx context.Context, project *models.CreateProjectRequest) (*models.Project, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Project, error)
+	ListByOrganization(ctx context.Context, orgID uuid.UUID) ([]models.Project, error)
+	ListForOrganization(ctx context.Context, orgID uuid.UUID, params listing.Params) (*listing.Page[models.Project], error)
+}
+
+type projectRepository struct {
+	db *database.DB
+}
+
+func NewProjectRepository(db *database.DB) ProjectRepository {
+	return &projectRepository{db: db}
+}
+
+func (r *projectRepository) Create(ctx context.Context, project *models.CreateProjectRequest) (*models.Project, error) {
+	query := `
+		INSERT INTO projects (organization_id, name, description, status, start_date, end_date)
+		VALUES ($1, $2, $3, COALESCE(NULLIF($4, '')::project_status, 'active'), $5, $6)
+		RETURNING ` + projectColumns
+
+	var result models.Project
+	err := scanProject(r.db.Conn(ctx).QueryRow(
+		ctx,
+		query,
+		project.OrganizationID,
+		project.Name,
+		project.Description,
+		string(project.Status),
+		project.StartDate,
+		project.EndDate,
+	), &result)
+
+	if err != nil {
+		return nil, fmt.Errorf("error creating project: %w", err)
+	}
+
+	return &result, nil
+}
+
+func (r *projectRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Project, error) {
+	query := `SELECT ` + projectColumns + ` FROM projects WHERE id = $1`
+
+	var project models.Project
+	err := scanProject(r.db.Conn(ctx).QueryRow(ctx, query, id), &project)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, apperror.NotFound("Project not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting project: %w", err)
+	}
+
+	return &project, nil
+}
+
+func (r *projectRepository) ListByOrganization(ctx context.Context, orgID uuid.UUID) ([]models.Project, error) {
+	query := `
+		SELECT ` + projectColumns + `
+		FROM projects
+		WHERE organization_id = $1
+		ORDER BY created_at
+	`
+
+	rows, err := r.db.ReadConn(ctx).Query(ctx, query, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("error listing projects: %w", err)
+	}
+	defer rows.Close()
+
+	var projects []models.Project
+	for rows.Next() {
+		var project models.Project
+		if err := scanProject(rows, &project); err != nil {
+			return nil, fmt.Errorf("error scanning project: %w", err)
+		}
+		projects = append(projects, project)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating projects: %w", err)
+	}
+
+	return projects, nil
+}
+
+// ListForOrganization returns a page of the projects of an organization
+func (r *projectRepository) ListForOrganization(ctx context.Context, orgID uuid.UUID, params listing.Params) (*listing.Page[models.Project], error) {
+	q := newListQuery("projects")
+	q.where("organization_id = " + q.arg(orgID))
+	q.apply(ProjectListSpec, params)
+
+	page, err := list(ctx, r.db.ReadConn(ctx), q, projectColumns, ProjectListSpec, params, scanProject)
+	if err != nil {
+		return nil, fmt.Errorf("error listing projects: %w", err)
+	}
+
+	return page, nil
+}
+
+func scanProject(row pgx.Row, project *models.Project) error {
+	return row.Scan(
+		&project.ID,
+		&project.OrganizationID,
+		&project.Name,
+		&project.Description,
+		&project.Status,
+		&project.StartDate,
+		&project.EndDate,
+		&project.CreatedAt,
+		&project.UpdatedAt,
+	)
+}
@@ -0,0 +1,173 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/atavada/project-management-saas/internal/database"
+	"github.com/atavada/project-management-saas/internal/listing"
+	"github.com/atavada/project-management-saas/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// taskActivityColumns selects an activity entry with its actor, the query
+// joins users as u onto task_activity as a
+const taskActivityColumns = `a.id, a.task_id, a.actor_id, a.type, a.from_value, a.to_value, a.body, a.created_at,
+		u.id, COALESCE(u.first_name, ''), COALESCE(u.last_name, ''), COALESCE(u.avatar_url, '')`
+
+// TaskActivityListSpec is what the history of a task can be filtered by. It
+// reads oldest first and is paged with cursors
+var TaskActivityListSpec = listing.Spec{
+	Sorts: map[string]string{
+		"created_at": "a.created_at",
+	},
+	DefaultSort: []listing.Sort{{Field: "created_at"}},
+	Tiebreak:    "a.id",
+	Filters: map[string]listing.Filter{
+		"type": {
+			Column: "a.type",
+			Op:     listing.Eq,
+			Type:   listing.Enum,
+			Values: []string{
+				string(models.TaskActivityStatusChanged),
+				string(models.TaskActivityReassigned),
+				string(models.TaskActivityPriorityChanged),
+				string(models.TaskActivityDueDateChanged),
+				string(models.TaskActivityCommented),
+			},
+			Cast:  "task_activity_type",
+			Multi: true,
+		},
+		"actor_id": {Column: "a.actor_id", Op: listing.Eq, Type: listing.UUID, Multi: true},
+	},
+	Keyset: true,
+}
+
+// TaskActivityRepository stores the history of tasks. Entries are never
+// changed once written
+type TaskActivityRepository interface {
+	Create(ctx context.Context, activity *models.TaskActivity) (*models.TaskActivity, error)
+	ListForTask(ctx context.Context, taskID uuid.UUID, params listing.Params) (*listing.Page[models.TaskActivity], error)
+	ListByActor(ctx context.Context, userID uuid.UUID) ([]models.TaskActivity, error)
+}
+
+type taskActivityRepository struct {
+	db *database.DB
+}
+
+func NewTaskActivityRepository(db *database.DB) TaskActivityRepository {
+	return &taskActivityRepository{db: db}
+}
+
+// Create writes an entry and returns it with its actor
+func (r *taskActivityRepository) Create(ctx context.Context, activity *models.TaskActivity) (*models.TaskActivity, error) {
+	query := `
+		WITH a AS (
+			INSERT INTO task_activity (task_id, actor_id, type, from_value, to_value, body)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			RETURNING *
+		)
+		SELECT ` + taskActivityColumns + `
+		FROM a
+		LEFT JOIN users u ON u.id = a.actor_id
+	`
+
+	var result models.TaskActivity
+	err := scanTaskActivity(r.db.Conn(ctx).QueryRow(
+		ctx,
+		query,
+		activity.TaskID,
+		activity.ActorID,
+		string(activity.Type),
+		activity.From,
+		activity.To,
+		activity.Body,
+	), &result)
+
+	if err != nil {
+		return nil, fmt.Errorf("error creating task activity: %w", err)
+	}
+
+	return &result, nil
+}
+
+// ListForTask returns a page of the history of a task
+func (r *taskActivityRepository) ListForTask(ctx context.Context, taskID uuid.UUID, params listing.Params) (*listing.Page[models.TaskActivity], error) {
+	q := newListQuery("task_activity a LEFT JOIN users u ON u.id = a.actor_id")
+	q.where("a.task_id = " + q.arg(taskID))
+	q.apply(TaskActivityListSpec, params)
+
+	page, err := list(ctx, r.db.ReadConn(ctx), q, taskActivityColumns, TaskActivityListSpec, params, scanTaskActivity)
+	if err != nil {
+		return nil, fmt.Errorf("error listing task activity: %w", err)
+	}
+
+	return page, nil
+}
+
+// ListByActor returns every entry the user made, comments included, across all
+// organizations, oldest first
+func (r *taskActivityRepository) ListByActor(ctx context.Context, userID uuid.UUID) ([]models.TaskActivity, error) {
+	query := `
+		SELECT ` + taskActivityColumns + `
+		FROM task_activity a
+		LEFT JOIN users u ON u.id = a.actor_id
+		WHERE a.actor_id = $1
+		ORDER BY a.created_at, a.id
+	`
+
+	rows, err := r.db.ReadConn(ctx).Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error listing task activity by actor: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []models.TaskActivity
+	for rows.Next() {
+		var activity models.TaskActivity
+		if err := scanTaskActivity(rows, &activity); err != nil {
+			return nil, fmt.Errorf("error scanning task activity: %w", err)
+		}
+		entries = append(entries, activity)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating task activity: %w", err)
+	}
+
+	return entries, nil
+}
+
+// scanTaskActivity reads an entry and its actor, which is nil when the
+// entry has none or the user was deleted
+func scanTaskActivity(row pgx.Row, activity *models.TaskActivity) error {
+	var actorID *uuid.UUID
+	var actor models.TaskActivityActor
+
+	err := row.Scan(
+		&activity.ID,
+		&activity.TaskID,
+		&activity.ActorID,
+		&activity.Type,
+		&activity.From,
+		&activity.To,
+		&activity.Body,
+		&activity.CreatedAt,
+		&actorID,
+		&actor.FirstName,
+		&actor.LastName,
+		&actor.AvatarURL,
+	)
+	if err != nil {
+		return err
+	}
+
+	activity.Actor = nil
+	if actorID != nil {
+		actor.ID = *actorID
+		activity.Actor = &actor
+	}
+
+	return nil
+}
@@ -0,0 +1,263 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/atavada/project-management-saas/internal/apperror"
+	"github.com/atavada/project-management-saas/internal/database"
+	"github.com/atavada/project-management-saas/internal/listing"
+	"github.com/atavada/project-management-saas/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const taskColumns = `id, project_id, assigned_to, created_by, title, COALESCE(description, ''), status, priority,
+		due_date, COALESCE(reminder_sent, FALSE), completed_at, created_at, updated_at`
+
+// TaskListSpec is what the tasks of an organization can be listed by. Columns
+// are qualified with t, the list joins projects to scope tasks to the
+// organization. Orgs accumulate a lot of tasks, so the list is paged with
+// cursors; keys like due_date and status are served by their indexes
+var TaskListSpec = listing.Spec{
+	Sorts: map[string]string{
+		"title":      "t.title",
+		"status":     "t.status",
+		"priority":   "t.priority",
+		"due_date":   "t.due_date",
+		"created_at": "t.created_at",
+		"updated_at": "t.updated_at",
+	},
+	DefaultSort: []listing.Sort{{Field: "created_at"}},
+	Tiebreak:    "t.id",
+	Filters: map[string]listing.Filter{
+		"project_id":  {Column: "t.project_id", Op: listing.Eq, Type: listing.UUID, Multi: true},
+		"assigned_to": {Column: "t.assigned_to", Op: listing.Eq, Type: listing.UUID, Multi: true},
+		"created_by":  {Column: "t.created_by", Op: listing.Eq, Type: listing.UUID, Multi: true},
+		"status": {
+			Column: "t.status",
+			Op:     listing.Eq,
+			Type:   listing.Enum,
+			Values: []string{string(models.TaskStatusTodo), string(models.TaskStatusInProgress), string(models.TaskStatusDone)},
+			Cast:   "task_status",
+			Multi:  true,
+		},
+		"priority": {
+			Column: "t.priority",
+			Op:     listing.Eq,
+			Type:   listing.Enum,
+			Values: []string{string(models.PriorityLow), string(models.PriorityMedium), string(models.PriorityHigh)},
+			Cast:   "task_priority",
+			Multi:  true,
+		},
+		"due_after":  {Column: "t.due_date", Op: listing.Gte, Type: listing.Date},
+		"due_before": {Column: "t.due_date", Op: listing.Lte, Type: listing.Date},
+	},
+	Search: []string{"t.title"},
+	Keyset: true,
+}
+
+// qualifiedTaskColumns is taskColumns for queries joining other tables
+const qualifiedTaskColumns = `t.id, t.project_id, t.assigned_to, t.created_by, t.title, COALESCE(t.description, ''), t.status, t.priority,
+		t.due_date, COALESCE(t.reminder_sent, FALSE), t.completed_at, t.created_at, t.updated_at`
+
+// TaskRepository stores tasks, which belong to projects
+type TaskRepository interface {
+	Create(ctx context.Context, task *models.CreateTaskRequest) (*models.Task, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Task, error)
+	GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*models.Task, error)
+	Update(ctx context.Context, task *models.Task) (*models.Task, error)
+	ListByCreator(ctx context.Context, userID uuid.UUID) ([]models.Task, error)
+	ListByAssignee(ctx context.Context, userID uuid.UUID) ([]models.Task, error)
+	ListForOrganization(ctx context.Context, orgID uuid.UUID, params listing.Params) (*listing.Page[models.Task], error)
+}
+
+type taskRepository struct {
+	db *database.DB
+}
+
+func NewTaskRepository(db *database.DB) TaskRepository {
+	return &taskRepository{db: db}
+}
+
+func (r *taskRepository) Create(ctx context.Context, task *models.CreateTaskRequest) (*models.Task, error) {
+	query := `
+		INSERT INTO tasks (project_id, assigned_to, created_by, title, description, status, priority, due_date, completed_at)
+		VALUES (
+			$1, $2, $3, $4, $5,
+			COALESCE(NULLIF($6, '')::task_status, 'todo'),
+			COALESCE(NULLIF($7, '')::task_priority, 'medium'),
+			$8,
+			CASE WHEN $6 = 'done' THEN CURRENT_TIMESTAMP END
+		)
+		RETURNING ` + taskColumns
+
+	var result models.Task
+	err := scanTask(r.db.Conn(ctx).QueryRow(
+		ctx,
+		query,
+		task.ProjectID,
+		task.AssignedTo,
+		task.CreatedBy,
+		task.Title,
+		task.Description,
+		string(task.Status),
+		string(task.Priority),
+		task.DueDate,
+	), &result)
+
+	if err != nil {
+		return nil, fmt.Errorf("error creating task: %w", err)
+	}
+
+	return &result, nil
+}
+
+func (r *taskRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Task, error) {
+	return r.get(ctx, `SELECT `+taskColumns+` FROM tasks WHERE id = $1`, id)
+}
+
+// GetByIDForUpdate is GetByID locking the row until the transaction in ctx
+// ends, so a read-modify-write of the task can't lose a concurrent update
+func (r *taskRepository) GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*models.Task, error) {
+	return r.get(ctx, `SELECT `+taskColumns+` FROM tasks WHERE id = $1 FOR UPDATE`, id)
+}
+
+func (r *taskRepository) get(ctx context.Context, query string, id uuid.UUID) (*models.Task, error) {
+	var task models.Task
+	err := scanTask(r.db.Conn(ctx).QueryRow(ctx, query, id), &task)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, apperror.NotFound("Task not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting task: %w", err)
+	}
+
+	return &task, nil
+}
+
+// Update saves the editable fields of a task. Moving it to done stamps
+// completed_at and moving it out clears it; a new due date resets the reminder
+func (r *taskRepository) Update(ctx context.Context, task *models.Task) (*models.Task, error) {
+	query := `
+		UPDATE tasks
+		SET title = $2,
+			description = $3,
+			status = $4::task_status,
+			priority = $5::task_priority,
+			assigned_to = $6,
+			due_date = $7,
+			completed_at = CASE WHEN $4 = 'done' THEN COALESCE(completed_at, CURRENT_TIMESTAMP) END,
+			reminder_sent = CASE WHEN due_date IS DISTINCT FROM $7 THEN FALSE ELSE reminder_sent END
+		WHERE id = $1
+		RETURNING ` + taskColumns
+
+	var result models.Task
+	err := scanTask(r.db.Conn(ctx).QueryRow(
+		ctx,
+		query,
+		task.ID,
+		task.Title,
+		task.Description,
+		string(task.Status),
+		string(task.Priority),
+		task.AssignedTo,
+		task.DueDate,
+	), &result)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, apperror.NotFound("Task not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error updating task: %w", err)
+	}
+
+	return &result, nil
+}
+
+// ListByCreator returns every task created by the user, across all organizations
+func (r *taskRepository) ListByCreator(ctx context.Context, userID uuid.UUID) ([]models.Task, error) {
+	query := `
+		SELECT ` + taskColumns + `
+		FROM tasks
+		WHERE created_by = $1
+		ORDER BY created_at
+	`
+
+	rows, err := r.db.ReadConn(ctx).Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error listing tasks by creator: %w", err)
+	}
+
+	return scanTasks(rows)
+}
+
+// ListByAssignee returns every task assigned to the user, across all organizations
+func (r *taskRepository) ListByAssignee(ctx context.Context, userID uuid.UUID) ([]models.Task, error) {
+	query := `
+		SELECT ` + taskColumns + `
+		FROM tasks
+		WHERE assigned_to = $1
+		ORDER BY created_at
+	`
+
+	rows, err := r.db.ReadConn(ctx).Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error listing tasks by assignee: %w", err)
+	}
+
+	return scanTasks(rows)
+}
+
+// ListForOrganization returns a page of the tasks in all projects of an organization
+func (r *taskRepository) ListForOrganization(ctx context.Context, orgID uuid.UUID, params listing.Params) (*listing.Page[models.Task], error) {
+	q := newListQuery("tasks t INNER JOIN projects p ON p.id = t.project_id")
+	q.where("p.organization_id = " + q.arg(orgID))
+	q.apply(TaskListSpec, params)
+
+	page, err := list(ctx, r.db.ReadConn(ctx), q, qualifiedTaskColumns, TaskListSpec, params, scanTask)
+	if err != nil {
+		return nil, fmt.Errorf("error listing tasks: %w", err)
+	}
+
+	return page, nil
+}
+
+func scanTask(row pgx.Row, task *models.Task) error {
+	return row.Scan(
+		&task.ID,
+		&task.ProjectID,
+		&task.AssignedTo,
+		&task.CreatedBy,
+		&task.Title,
+		&task.Description,
+		&task.Status,
+		&task.Priority,
+		&task.DueDate,
+		&task.ReminderSent,
+		&task.CompletedAt,
+		&task.CreatedAt,
+		&task.UpdatedAt,
+	)
+}
+
+func scanTasks(rows pgx.Rows) ([]models.Task, error) {
+	defer rows.Close()
+
+	var tasks []models.Task
+	for rows.Next() {
+		var task models.Task
+		if err := scanTask(rows, &task); err != nil {
+			return nil, fmt.Errorf("error scanning task: %w", err)
+		}
+		tasks = append(tasks, task)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating tasks: %w", err)
+	}
+
+	return tasks, nil
+}
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/atavada/project-management-saas/internal/database"
+	"github.com/atavada/project-management-saas/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// UserPreferencesRepository stores the app-owned profile fields of users
+type UserPreferencesRepository interface {
+	// Get returns nil when the user has never saved preferences
+	Get(ctx context.Context, userID uuid.UUID) (*models.UserPreferences, error)
+	Upsert(ctx context.Context, prefs *models.UserPreferences) (*models.UserPreferences, error)
+}
+
+type userPreferencesRepository struct {
+	db *database.DB
+}
+
+func NewUserPreferencesRepository(db *database.DB) UserPreferencesRepository {
+	return &userPreferencesRepository{db: db}
+}
+
+// Get returns nil when the user has never saved preferences
+func (r *userPreferencesRepository) Get(ctx context.Context, userID uuid.UUID) (*models.UserPreferences, error) {
+	query := `
+		SELECT user_id, timezone, locale, display_name, notification_preferences, updated_at
+		FROM user_preferences
+		WHERE user_id = $1
+	`
+
+	var prefs models.UserPreferences
+	err := r.db.Conn(ctx).QueryRow(ctx, query, userID).Scan(
+		&prefs.UserID,
+		&prefs.Timezone,
+		&prefs.Locale,
+		&prefs.DisplayName,
+		&prefs.Notifications,
+		&prefs.UpdatedAt,
+	)
+
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting user preferences: %w", err)
+	}
+
+	return &prefs, nil
+}
+
+func (r *userPreferencesRepository) Upsert(ctx context.Context, prefs *models.UserPreferences) (*models.UserPreferences, error) {
+	query := `
+		INSERT INTO user_preferences (user_id, timezone, locale, display_name, notification_preferences)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id)
+		DO UPDATE SET
+				timezone = EXCLUDED.timezone,
+				locale = EXCLUDED.locale,
+				display_name = EXCLUDED.display_name,
+				notification_preferences = EXCLUDED.notification_preferences
+		RETURNING user_id, timezone, locale, display_name, notification_preferences, updated_at
+	`
+
+	var result models.UserPreferences
+	err := r.db.Conn(ctx).QueryRow(
+		ctx,
+		query,
+		prefs.UserID,
+		prefs.Timezone,
+		prefs.Locale,
+		prefs.DisplayName,
+		prefs.Notifications,
+	).Scan(
+		&result.UserID,
+		&result.Timezone,
+		&result.Locale,
+		&result.DisplayName,
+		&result.Notifications,
+		&result.UpdatedAt,
+	)
+
+	if err != nil {
+		return nil, fmt.Errorf("error upserting user preferences: %w", err)
+	}
+
+	return &result, nil
+}
@@ -2,23 +2,35 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
+	"github.com/atavada/project-management-saas/internal/apperror"
 	"github.com/atavada/project-management-saas/internal/database"
 	"github.com/atavada/project-management-saas/internal/models"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 )
 
-type UserRepository struct {
+// UserRepository stores the users synced from Clerk. Lookups of missing users
+// fail with an apperror NotFound error
+type UserRepository interface {
+	Create(ctx context.Context, user *models.CreateUserRequest) (*models.User, error)
+	GetByClerkID(ctx context.Context, clerkUserID string) (*models.User, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*models.User, error)
+	Upsert(ctx context.Context, user *models.CreateUserRequest) (*models.User, error)
+	Anonymize(ctx context.Context, id uuid.UUID) (*models.User, error)
+}
+
+type userRepository struct {
 	db *database.DB
 }
 
-func NewUserRepository(db *database.DB) *UserRepository {
-	return &UserRepository{db: db}
+func NewUserRepository(db *database.DB) UserRepository {
+	return &userRepository{db: db}
 }
 
-func (r *UserRepository) Create(ctx context.Context, user *models.CreateUserRequest) (*models.User, error) {
+func (r *userRepository) Create(ctx context.Context, user *models.CreateUserRequest) (*models.User, error) {
 	query := `
 		INSERT INTO users (clerk_user_id, email, first_name, last_name, avatar_url)
 		VALUES ($1, $2, $3, $4, $5)
@@ -26,7 +38,7 @@ func (r *UserRepository) Create(ctx context.Context, user *models.CreateUserRequ
 	`
 	
 	var result models.User
-	err := r.db.Pool.QueryRow(
+	err := r.db.Conn(ctx).QueryRow(
 		ctx,
 		query,
 		user.ClerkUserID,
@@ -45,6 +57,9 @@ func (r *UserRepository) Create(ctx context.Context, user *models.CreateUserRequ
 		&result.UpdatedAt,
 	)
 
+	if isUniqueViolation(err) {
+		return nil, apperror.Conflict("User already exists").Wrap(err)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("error creating user: %w", err)
 	}
@@ -52,7 +67,7 @@ func (r *UserRepository) Create(ctx context.Context, user *models.CreateUserRequ
 	return &result, nil
 }
 
-func (r *UserRepository) GetByClerkID(ctx context.Context, clerkUserID string) (*models.User, error) {
+func (r *userRepository) GetByClerkID(ctx context.Context, clerkUserID string) (*models.User, error) {
 	query := `
 		SELECT id, clerk_user_id, email, first_name, last_name, avatar_url, created_at, updated_at
 		FROM users
@@ -60,7 +75,7 @@ func (r *UserRepository) GetByClerkID(ctx context.Context, clerkUserID string) (
 	`
 
 	var user models.User
-	err := r.db.Pool.QueryRow(ctx, query, clerkUserID).Scan(
+	err := r.db.Conn(ctx).QueryRow(ctx, query, clerkUserID).Scan(
 		&user.ID,
 		&user.ClerkUserID,
 		&user.Email,
@@ -71,8 +86,8 @@ func (r *UserRepository) GetByClerkID(ctx context.Context, clerkUserID string) (
 		&user.UpdatedAt,
 	)
 
-	if err == pgx.ErrNoRows {
-		return nil, nil
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, apperror.NotFound("User not found")
 	}
 	if err != nil {
 		return nil, fmt.Errorf("error getting user: %w", err)
@@ -81,7 +96,7 @@ func (r *UserRepository) GetByClerkID(ctx context.Context, clerkUserID string) (
 	return &user, nil
 } 
 
-func (r *UserRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
+func (r *userRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
 	query := `
 		SELECT id, clerk_user_id, email, first_name, last_name, avatar_url, created_at, updated_at
 		FROM users
@@ -89,7 +104,7 @@ func (r *UserRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Use
 	`
 
 	var user models.User
-	err := r.db.Pool.QueryRow(ctx, query, id).Scan(
+	err := r.db.Conn(ctx).QueryRow(ctx, query, id).Scan(
 		&user.ID,
 		&user.ClerkUserID,
 		&user.Email,
@@ -100,8 +115,8 @@ func (r *UserRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Use
 		&user.UpdatedAt,
 	)
 
-	if err == pgx.ErrNoRows {
-		return nil, nil
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, apperror.NotFound("User not found")
 	}
 	if err != nil {
 		return nil, fmt.Errorf("error getting user: %w", err)
@@ -110,7 +125,7 @@ func (r *UserRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Use
 	return &user, nil
 }
 
-func (r *UserRepository) Upsert(ctx context.Context, user *models.CreateUserRequest) (*models.User, error) {
+func (r *userRepository) Upsert(ctx context.Context, user *models.CreateUserRequest) (*models.User, error) {
 	query := `
 		INSERT INTO users (clerk_user_id, email, first_name, last_name, avatar_url)
 		VALUES ($1, $2, $3, $4, $5)
@@ -125,7 +140,7 @@ func (r *UserRepository) Upsert(ctx context.Context, user *models.CreateUserRequ
 	`
 
 	var result models.User
-	err := r.db.Pool.QueryRow(
+	err := r.db.Conn(ctx).QueryRow(
 		ctx,
 		query,
 		user.ClerkUserID,
@@ -151,3 +166,69 @@ func (r *UserRepository) Upsert(ctx context.Context, user *models.CreateUserRequ
 	return &result, nil
 }
 
+// Anonymize erases the personal data of a user while keeping the row, so tasks
+// and history that reference the UUID stay intact. Preferences, exports and
+// memberships are removed along with it, and the audit events they made lose
+// the Clerk ID and IP address they were recorded with
+func (r *userRepository) Anonymize(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	// Users acting before their webhook arrived are named by Clerk ID, so this
+	// runs before the ID is replaced
+	auditQuery := `
+		UPDATE audit_events
+		SET actor_name = '',
+			ip_address = NULL
+		WHERE actor_type = 'user'
+			AND (actor_id = $1 OR actor_name = (SELECT clerk_user_id FROM users WHERE id = $1))
+	`
+
+	query := `
+		UPDATE users
+		SET clerk_user_id = 'deleted_' || id::text,
+				email = id::text || '@deleted.invalid',
+				first_name = 'Deleted',
+				last_name = 'User',
+				avatar_url = '',
+				anonymized_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+		RETURNING id, clerk_user_id, email, first_name, last_name, avatar_url, created_at, updated_at
+	`
+
+	var result models.User
+	err := r.db.WithTx(ctx, func(ctx context.Context) error {
+		tx := r.db.Conn(ctx)
+		if _, err := tx.Exec(ctx, auditQuery, id); err != nil {
+			return err
+		}
+
+		err := tx.QueryRow(ctx, query, id).Scan(
+			&result.ID,
+			&result.ClerkUserID,
+			&result.Email,
+			&result.FirstName,
+			&result.LastName,
+			&result.AvatarURL,
+			&result.CreatedAt,
+			&result.UpdatedAt,
+		)
+		if err != nil {
+			return err
+		}
+
+		for _, table := range []string{"user_preferences", "data_exports", "organization_members"} {
+			if _, err := tx.Exec(ctx, "DELETE FROM "+table+" WHERE user_id = $1", id); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, apperror.NotFound("User not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error anonymizing user: %w", err)
+	}
+
+	return &result, nil
+}
@@ -1,38 +1,73 @@
 package routes
 
 import (
+	"time"
+
+	"github.com/atavada/project-management-saas/internal/config"
 	"github.com/atavada/project-management-saas/internal/handlers"
 	"github.com/atavada/project-management-saas/internal/middleware"
 	"github.com/gofiber/fiber/v3"
 )
 
 type Handlers struct {
+	Health *handlers.HealthHandler
 	Webhook *handlers.WebhookHandler
 	User *handlers.UserHandler
 	Organization *handlers.OrganizationHandler
+	DataExport *handlers.DataExportHandler
+	Task *handlers.TaskHandler
+	Admin *handlers.AdminHandler
 }
 
-func SetupRoutes(app *fiber.App, h *Handlers, clerkSecretKey string) {
+// healthTimeout bounds the probes, orchestrators give up on them quickly anyway
+const healthTimeout = 5 * time.Second
+
+func SetupRoutes(app *fiber.App, h *Handlers, cfg *config.Config, verifier middleware.TokenVerifier) {
 	api := app.Group("/api/v1")
 
-	// Health check
-	api.Get("/health", func(c fiber.Ctx) error {
-		return c.JSON(fiber.Map{"status": "ok"})
-	})
+	// Health checks, /health is kept as an alias of the liveness probe
+	health := api.Group("/health", middleware.Timeout(healthTimeout))
+	health.Get("/", h.Health.Live)
+	health.Get("/live", h.Health.Live)
+	health.Get("/ready", h.Health.Ready)
 
-	// Webhook (no auth required)
-	webhooks := api.Group("/webhooks")
+	// Webhook (no auth required), Clerk retries deliveries that time out
+	webhooks := api.Group("/webhooks", middleware.Timeout(cfg.WebhookTimeout))
 	webhooks.Post("/clerk", h.Webhook.HandlerClerkWebhook)
 
-	// Protected routes
-	protected := api.Group("", middleware.AuthMiddleware(clerkSecretKey))
+	// Protected routes, the deadline also covers token verification
+	protected := api.Group("",
+		middleware.Timeout(cfg.RequestTimeout),
+		middleware.AuthMiddleware(verifier),
+	)
 
 	// User routes
 	users := protected.Group("/users")
 	users.Get("/me", h.User.GetCurrentUser)
+	users.Patch("/me", h.User.UpdateCurrentUser)
+
+	// Data exports of the current user
+	users.Post("/me/exports", h.DataExport.RequestExport)
+	users.Get("/me/exports/:id", h.DataExport.GetExport)
+	users.Get("/me/exports/:id/download", h.DataExport.DownloadExport)
 
-	// Organization routes
+	// Organization routes, :id accepts either the organization UUID or its slug
 	organization := protected.Group("/organizations")
 	organization.Get("/", h.Organization.ListUserOrganizations)
 	organization.Get("/:id", h.Organization.GetOrganization)
+	organization.Patch("/:id", h.Organization.UpdateOrganization)
+	organization.Get("/:id/members", h.Organization.ListMembers)
+	organization.Get("/:id/projects", h.Organization.ListProjects)
+	organization.Get("/:id/tasks", h.Organization.ListTasks)
+	organization.Get("/:id/audit-log", h.Organization.ListAuditLog)
+
+	// Task routes
+	tasks := protected.Group("/tasks")
+	tasks.Patch("/:id", h.Task.UpdateTask)
+	tasks.Post("/:id/comments", h.Task.CreateComment)
+	tasks.Get("/:id/activity", h.Task.ListActivity)
+
+	// Platform admin routes, limited to the users in ADMIN_USER_IDS
+	admin := protected.Group("/admin", middleware.RequireAdmin(cfg.AdminUserIDs))
+	admin.Delete("/users/:id", h.Admin.EraseUser)
 }
\ No newline at end of file
@@ -0,0 +1,267 @@
+// Package seed fills a database with a deterministic dataset for local
+// development and integration test fixtures. Users and organizations normally
+// only arrive through Clerk webhooks, so they are created with fake Clerk IDs.
+package seed
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/atavada/project-management-saas/internal/models"
+	"github.com/atavada/project-management-saas/internal/repository"
+)
+
+type Repositories struct {
+	Users    repository.UserRepository
+	Orgs     repository.OrganizationRepository
+	Members  repository.OrganizationMemberRepository
+	Projects repository.ProjectRepository
+	Tasks    repository.TaskRepository
+}
+
+// Options controls the size and shape of the generated dataset. The same
+// options always produce the same names, roles, statuses and date offsets
+type Options struct {
+	// Organizations is the number of organizations to create
+	Organizations int
+	// MembersPerOrg is the number of users per organization. The first is the
+	// owner, roughly a quarter of the rest are admins
+	MembersPerOrg int
+	// ProjectsPerStatus is the number of projects created in each project status
+	ProjectsPerStatus int
+	// TasksPerProject is the number of tasks in each project
+	TasksPerProject int
+	// Seed drives every random choice
+	Seed int64
+	// Today anchors start and due dates, it defaults to the current UTC date
+	Today time.Time
+}
+
+func DefaultOptions() Options {
+	return Options{
+		Organizations:     2,
+		MembersPerOrg:     5,
+		ProjectsPerStatus: 1,
+		TasksPerProject:   8,
+		Seed:              1,
+	}
+}
+
+// Dataset is everything a run created or found, for use as test fixtures
+type Dataset struct {
+	Organizations []Organization
+}
+
+type Organization struct {
+	*models.Organization
+	Members  []Member
+	Projects []Project
+}
+
+type Member struct {
+	*models.User
+	Role models.OrganizationRole
+}
+
+type Project struct {
+	*models.Project
+	Tasks []models.Task
+}
+
+// Counts summarises a dataset
+func (d *Dataset) Counts() (orgs, users, projects, tasks int) {
+	for _, org := range d.Organizations {
+		orgs++
+		users += len(org.Members)
+		for _, project := range org.Projects {
+			projects++
+			tasks += len(project.Tasks)
+		}
+	}
+	return orgs, users, projects, tasks
+}
+
+var (
+	companyNames = []string{"Acme", "Globex", "Initech", "Umbrella", "Hooli", "Stark", "Wayne", "Wonka", "Tyrell", "Cyberdyne"}
+	firstNames   = []string{"Alice", "Bob", "Carol", "Dave", "Erin", "Frank", "Grace", "Heidi", "Ivan", "Judy", "Mallory", "Niaj", "Olivia", "Peggy", "Rupert", "Sybil", "Trent", "Victor", "Walter"}
+	lastNames    = []string{"Anderson", "Brown", "Clark", "Davis", "Evans", "Garcia", "Harris", "Jackson", "King", "Lewis", "Martin", "Nelson", "Owens", "Parker", "Reed"}
+	projectNames = []string{"Website Redesign", "Mobile App", "Billing Revamp", "Onboarding Flow", "Data Warehouse", "Marketing Site", "API v2", "Support Portal"}
+	taskVerbs    = []string{"Design", "Implement", "Review", "Test", "Document", "Deploy", "Refactor", "Plan"}
+	taskNouns    = []string{"login page", "search", "notifications", "settings screen", "invoice export", "dashboard", "permissions", "audit trail", "reports", "integrations"}
+
+	projectStatuses = []models.ProjectStatus{models.ProjectStatusActive, models.ProjectStatusCompleted, models.ProjectStatusArchived}
+	taskStatuses    = []models.TaskStatus{models.TaskStatusTodo, models.TaskStatusInProgress, models.TaskStatusDone}
+	taskPriorities  = []models.TaskPriority{models.PriorityLow, models.PriorityMedium, models.PriorityHigh}
+)
+
+// Run generates the dataset described by opts. Users, organizations and roles
+// are upserted so runs can be repeated; projects and tasks are only created for
+// organizations that have no projects yet
+func Run(ctx context.Context, repos Repositories, opts Options) (*Dataset, error) {
+	if opts.Organizations < 1 || opts.MembersPerOrg < 1 {
+		return nil, fmt.Errorf("at least one organization and one member are required")
+	}
+	if opts.Today.IsZero() {
+		opts.Today = time.Now().UTC().Truncate(24 * time.Hour)
+	}
+
+	dataset := &Dataset{}
+
+	for o := 0; o < opts.Organizations; o++ {
+		// Each organization draws from its own source, so skipping the
+		// projects of one that is already seeded doesn't shift the names
+		// drawn for the next
+		rng := rand.New(rand.NewSource(opts.Seed + int64(o)))
+
+		org, err := seedOrganization(ctx, repos, rng, opts, o)
+		if err != nil {
+			return nil, err
+		}
+		dataset.Organizations = append(dataset.Organizations, *org)
+	}
+
+	return dataset, nil
+}
+
+func seedOrganization(ctx context.Context, repos Repositories, rng *rand.Rand, opts Options, o int) (*Organization, error) {
+	name := companyNames[o%len(companyNames)]
+	if o >= len(companyNames) {
+		name = fmt.Sprintf("%s %d", name, o/len(companyNames)+1)
+	}
+
+	org, err := repos.Orgs.Upsert(ctx, &models.CreateOrganizationRequest{
+		ClerkOrgID: fmt.Sprintf("seed_org_%03d", o+1),
+		Name:       name,
+		Slug:       strings.ToLower(strings.ReplaceAll(name, " ", "-")),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Organization{Organization: org}
+
+	for m := 0; m < opts.MembersPerOrg; m++ {
+		first := firstNames[rng.Intn(len(firstNames))]
+		last := lastNames[rng.Intn(len(lastNames))]
+
+		role := models.RoleMember
+		switch {
+		case m == 0:
+			role = models.RoleOwner
+		case m%4 == 1:
+			role = models.RoleAdmin
+		}
+
+		user, err := repos.Users.Upsert(ctx, &models.CreateUserRequest{
+			ClerkUserID: fmt.Sprintf("seed_user_%03d_%03d", o+1, m+1),
+			Email:       fmt.Sprintf("%s.%s.%d.%d@example.com", strings.ToLower(first), strings.ToLower(last), o+1, m+1),
+			FirstName:   first,
+			LastName:    last,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if err := repos.Members.SetRole(ctx, org.ID, user.ID, role); err != nil {
+			return nil, err
+		}
+
+		result.Members = append(result.Members, Member{User: user, Role: role})
+	}
+
+	existing, err := repos.Projects.ListByOrganization(ctx, org.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) > 0 {
+		for i := range existing {
+			result.Projects = append(result.Projects, Project{Project: &existing[i]})
+		}
+		return result, nil
+	}
+
+	p := 0
+	for _, status := range projectStatuses {
+		for n := 0; n < opts.ProjectsPerStatus; n++ {
+			project, err := seedProject(ctx, repos, rng, opts, result, status, p)
+			if err != nil {
+				return nil, err
+			}
+			result.Projects = append(result.Projects, *project)
+			p++
+		}
+	}
+
+	return result, nil
+}
+
+func seedProject(ctx context.Context, repos Repositories, rng *rand.Rand, opts Options, org *Organization, status models.ProjectStatus, p int) (*Project, error) {
+	name := projectNames[p%len(projectNames)]
+	if p >= len(projectNames) {
+		name = fmt.Sprintf("%s %d", name, p/len(projectNames)+1)
+	}
+
+	start := opts.Today.AddDate(0, 0, -30-rng.Intn(60))
+	var end *time.Time
+	if status != models.ProjectStatusActive {
+		e := start.AddDate(0, 0, 14+rng.Intn(30))
+		end = &e
+	}
+
+	project, err := repos.Projects.Create(ctx, &models.CreateProjectRequest{
+		OrganizationID: org.ID,
+		Name:           name,
+		Description:    fmt.Sprintf("Seeded %s project for %s", status, org.Name),
+		Status:         status,
+		StartDate:      &start,
+		EndDate:        end,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Project{Project: project}
+
+	for t := 0; t < opts.TasksPerProject; t++ {
+		taskStatus := taskStatuses[rng.Intn(len(taskStatuses))]
+		if status != models.ProjectStatusActive {
+			taskStatus = models.TaskStatusDone
+		}
+
+		// Leave some tasks unassigned and some without a due date, with due
+		// dates spread from overdue to a few weeks out
+		var assignee *models.User
+		if rng.Intn(5) > 0 {
+			assignee = org.Members[rng.Intn(len(org.Members))].User
+		}
+		var due *time.Time
+		if rng.Intn(4) > 0 {
+			d := opts.Today.AddDate(0, 0, rng.Intn(42)-14)
+			due = &d
+		}
+
+		req := &models.CreateTaskRequest{
+			ProjectID:   project.ID,
+			CreatedBy:   org.Members[rng.Intn(len(org.Members))].ID,
+			Title:       fmt.Sprintf("%s %s", taskVerbs[rng.Intn(len(taskVerbs))], taskNouns[rng.Intn(len(taskNouns))]),
+			Description: fmt.Sprintf("Seeded task %d of %s", t+1, name),
+			Status:      taskStatus,
+			Priority:    taskPriorities[rng.Intn(len(taskPriorities))],
+			DueDate:     due,
+		}
+		if assignee != nil {
+			req.AssignedTo = &assignee.ID
+		}
+
+		task, err := repos.Tasks.Create(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		result.Tasks = append(result.Tasks, *task)
+	}
+
+	return result, nil
+}
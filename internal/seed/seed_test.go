@@ -0,0 +1,72 @@
+package seed_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/atavada/project-management-saas/internal/repository/memory"
+	"github.com/atavada/project-management-saas/internal/seed"
+)
+
+func TestRunIsRepeatable(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	repos := seed.Repositories{
+		Users:    store.Users(),
+		Orgs:     store.Organizations(),
+		Members:  store.Members(),
+		Projects: store.Projects(),
+		Tasks:    store.Tasks(),
+	}
+
+	opts := seed.DefaultOptions()
+	opts.Organizations = 3
+	opts.Today = time.Date(2026, time.March, 2, 0, 0, 0, 0, time.UTC)
+
+	first, err := seed.Run(ctx, repos, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := seed.Run(ctx, repos, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	orgs, users, projects, tasks := second.Counts()
+	if orgs != 3 || users != 3*opts.MembersPerOrg || projects != 3*3*opts.ProjectsPerStatus || tasks != 0 {
+		t.Errorf("second run found %d orgs, %d users, %d projects and created %d tasks", orgs, users, projects, tasks)
+	}
+
+	for o, org := range second.Organizations {
+		if org.ID != first.Organizations[o].ID {
+			t.Errorf("organization %d was recreated", o)
+		}
+		for m, member := range org.Members {
+			want := first.Organizations[o].Members[m]
+			if member.ID != want.ID || member.Email != want.Email || member.FirstName != want.FirstName || member.Role != want.Role {
+				t.Errorf("member %d of organization %d changed from %s (%s) to %s (%s)", m, o, want.Email, want.Role, member.Email, member.Role)
+			}
+		}
+	}
+
+	// Another store seeded with the same options gets the same people
+	other := memory.NewStore()
+	fresh, err := seed.Run(ctx, seed.Repositories{
+		Users:    other.Users(),
+		Orgs:     other.Organizations(),
+		Members:  other.Members(),
+		Projects: other.Projects(),
+		Tasks:    other.Tasks(),
+	}, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for o, org := range fresh.Organizations {
+		for m, member := range org.Members {
+			if want := first.Organizations[o].Members[m].Email; member.Email != want {
+				t.Errorf("member %d of organization %d is %s, want %s", m, o, member.Email, want)
+			}
+		}
+	}
+}
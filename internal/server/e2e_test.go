@@ -0,0 +1,171 @@
+package server_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/atavada/project-management-saas/internal/models"
+	"github.com/atavada/project-management-saas/internal/repository"
+	"github.com/atavada/project-management-saas/internal/testenv"
+)
+
+// These tests run the whole API on Postgres and are skipped without
+// DATABASE_URL, see testenv
+
+func TestSignedWebhookDeliveries(t *testing.T) {
+	env := testenv.New(t)
+
+	userData := testenv.UserData("user_1", "ada@example.com", "Ada", "Lovelace")
+
+	rejected := []struct {
+		name    string
+		webhook *testenv.Webhook
+	}{
+		{"wrong secret", env.Webhooks.Event("user.created", userData).SignedWith("whsec_b3RoZXItc2lnbmluZy1zZWNyZXQ=")},
+		{"stale timestamp", env.Webhooks.Event("user.created", userData).At(time.Now().Add(-time.Hour))},
+	}
+	for _, tt := range rejected {
+		t.Run(tt.name, func(t *testing.T) {
+			expectStatus(t, env.Deliver(t, tt.webhook), http.StatusUnauthorized)
+		})
+	}
+
+	// Nothing was synced by the rejected deliveries
+	expectStatus(t, env.Do(t, http.MethodGet, "/api/v1/users/me", "user_1", nil), http.StatusUnauthorized)
+
+	deliver(t, env, env.Webhooks.Event("user.created", userData))
+	deliver(t, env, env.Webhooks.Event("user.updated", testenv.UserData("user_1", "ada@analytical.example", "Ada", "Lovelace")))
+
+	resp := env.Do(t, http.MethodGet, "/api/v1/users/me", "user_1", nil)
+	expectStatus(t, resp, http.StatusOK)
+
+	var body struct {
+		Data models.UserProfile `json:"data"`
+	}
+	resp.Decode(t, &body)
+	if body.Data.Email != "ada@analytical.example" {
+		t.Errorf("got email %q, want the updated one", body.Data.Email)
+	}
+
+	deliver(t, env, env.Webhooks.Event("user.deleted", testenv.DeletedUserData("user_1")))
+	expectStatus(t, env.Do(t, http.MethodGet, "/api/v1/users/me", "user_1", nil), http.StatusUnauthorized)
+}
+
+func TestOrganizationAccess(t *testing.T) {
+	env := testenv.New(t)
+
+	for _, id := range []string{"user_owner", "user_member", "user_outsider"} {
+		deliver(t, env, env.Webhooks.Event("user.created", testenv.UserData(id, id+"@example.com", "Test", "User")))
+	}
+	deliver(t, env, env.Webhooks.Event("organization.created", testenv.OrganizationData("org_1", "Acme", "acme", "user_owner")))
+	deliver(t, env, env.Webhooks.Event("organizationMembership.created",
+		testenv.MembershipData("orgmem_1", "org_1", "user_member", "org:member")))
+
+	tests := []struct {
+		name        string
+		path        string
+		clerkUserID string
+		status      int
+	}{
+		{"owner", "/api/v1/organizations/acme", "user_owner", http.StatusOK},
+		{"member", "/api/v1/organizations/acme", "user_member", http.StatusOK},
+		{"member lists members", "/api/v1/organizations/acme/members", "user_member", http.StatusOK},
+		{"member reads audit log", "/api/v1/organizations/acme/audit-log", "user_member", http.StatusForbidden},
+		{"owner reads audit log", "/api/v1/organizations/acme/audit-log", "user_owner", http.StatusOK},
+		{"non-member", "/api/v1/organizations/acme", "user_outsider", http.StatusForbidden},
+		{"unknown organization", "/api/v1/organizations/globex", "user_member", http.StatusNotFound},
+		{"user not synced yet", "/api/v1/organizations/acme", "user_unknown", http.StatusUnauthorized},
+		{"no token", "/api/v1/organizations/acme", "", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expectStatus(t, env.Do(t, http.MethodGet, tt.path, tt.clerkUserID, nil), tt.status)
+		})
+	}
+
+	var members struct {
+		Data []models.OrganizationMember `json:"data"`
+	}
+	resp := env.Do(t, http.MethodGet, "/api/v1/organizations/acme/members", "user_owner", nil)
+	expectStatus(t, resp, http.StatusOK)
+	resp.Decode(t, &members)
+	if len(members.Data) != 2 {
+		t.Errorf("got %d members, want the owner and the member: %s", len(members.Data), resp.Body)
+	}
+
+	// A rename in Clerk keeps the old slug working through a redirect
+	deliver(t, env, env.Webhooks.Event("organization.updated", testenv.OrganizationData("org_1", "Acme", "acme-inc", "user_owner")))
+	resp = env.Do(t, http.MethodGet, "/api/v1/organizations/acme/members", "user_member", nil)
+	expectStatus(t, resp, http.StatusPermanentRedirect)
+	if location := resp.Header.Get("Location"); location != "/api/v1/organizations/acme-inc/members" {
+		t.Errorf("redirected to %q", location)
+	}
+
+	// Removing the membership in Clerk revokes access
+	deliver(t, env, env.Webhooks.Event("organizationMembership.deleted",
+		testenv.MembershipData("orgmem_1", "org_1", "user_member", "org:member")))
+	expectStatus(t, env.Do(t, http.MethodGet, "/api/v1/organizations/acme-inc", "user_member", nil), http.StatusForbidden)
+}
+
+func TestTaskAccess(t *testing.T) {
+	env := testenv.New(t)
+	ctx := context.Background()
+
+	for _, id := range []string{"user_owner", "user_outsider"} {
+		deliver(t, env, env.Webhooks.Event("user.created", testenv.UserData(id, id+"@example.com", "Test", "User")))
+	}
+	deliver(t, env, env.Webhooks.Event("organization.created", testenv.OrganizationData("org_1", "Acme", "acme", "user_owner")))
+
+	// Projects and tasks have no API yet, they are written to the database
+	owner, err := repository.NewUserRepository(env.DB).GetByClerkID(ctx, "user_owner")
+	if err != nil {
+		t.Fatal(err)
+	}
+	org, err := repository.NewOrganizationRepository(env.DB).GetBySlug(ctx, "acme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	project, err := repository.NewProjectRepository(env.DB).Create(ctx, &models.CreateProjectRequest{OrganizationID: org.ID, Name: "Launch"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	task, err := repository.NewTaskRepository(env.DB).Create(ctx, &models.CreateTaskRequest{
+		ProjectID: project.ID,
+		CreatedBy: owner.ID,
+		Title:     "Write the announcement",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := "/api/v1/tasks/" + task.ID.String()
+	expectStatus(t, env.Do(t, http.MethodPatch, path, "user_outsider", map[string]any{"status": "done"}), http.StatusNotFound)
+	expectStatus(t, env.Do(t, http.MethodPatch, path, "user_owner", map[string]any{"status": "done"}), http.StatusOK)
+
+	resp := env.Do(t, http.MethodGet, path+"/activity", "user_owner", nil)
+	expectStatus(t, resp, http.StatusOK)
+
+	var activity struct {
+		Data []models.TaskActivity `json:"data"`
+	}
+	resp.Decode(t, &activity)
+	if len(activity.Data) != 1 || activity.Data[0].Type != models.TaskActivityStatusChanged {
+		t.Errorf("got %s, want one status change", resp.Body)
+	}
+}
+
+func deliver(t *testing.T, env *testenv.Env, webhook *testenv.Webhook) {
+	t.Helper()
+
+	expectStatus(t, env.Deliver(t, webhook), http.StatusOK)
+}
+
+func expectStatus(t *testing.T, resp *testenv.Response, status int) {
+	t.Helper()
+
+	if resp.StatusCode != status {
+		t.Fatalf("got %d, want %d: %s", resp.StatusCode, status, resp.Body)
+	}
+}
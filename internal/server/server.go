@@ -0,0 +1,173 @@
+// Package server wires repositories, services and handlers into the Fiber app.
+// The API binary and the end-to-end test harness build the same app from it
+package server
+
+import (
+	"context"
+
+	"github.com/atavada/project-management-saas/internal/config"
+	"github.com/atavada/project-management-saas/internal/database"
+	"github.com/atavada/project-management-saas/internal/handlers"
+	"github.com/atavada/project-management-saas/internal/metrics"
+	"github.com/atavada/project-management-saas/internal/middleware"
+	"github.com/atavada/project-management-saas/internal/repository"
+	"github.com/atavada/project-management-saas/internal/routes"
+	"github.com/atavada/project-management-saas/internal/services"
+	"github.com/atavada/project-management-saas/internal/validation"
+	"github.com/gofiber/fiber/v3"
+	"github.com/gofiber/fiber/v3/middleware/adaptor"
+	"github.com/gofiber/fiber/v3/middleware/cors"
+	"github.com/gofiber/fiber/v3/middleware/recover"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Server is the HTTP app together with the background workers it started
+type Server struct {
+	App *fiber.App
+
+	exportService *services.ExportService
+}
+
+// Repositories are the stores the app is built on
+type Repositories struct {
+	Users         repository.UserRepository
+	Organizations repository.OrganizationRepository
+	Members       repository.OrganizationMemberRepository
+	Preferences   repository.UserPreferencesRepository
+	Projects      repository.ProjectRepository
+	Tasks         repository.TaskRepository
+	Exports       repository.DataExportRepository
+	AuditEvents   repository.AuditEventRepository
+	TaskActivity  repository.TaskActivityRepository
+}
+
+// New builds the app on db. verifier checks the bearer tokens of protected
+// routes, the API passes a ClerkVerifier. clerkUsers deletes the Clerk
+// accounts of erased users
+func New(
+	cfg *config.Config,
+	db *database.DB,
+	migrator *database.Migrator,
+	verifier middleware.TokenVerifier,
+	clerkUsers services.ClerkUsers,
+	version string,
+) *Server {
+	repos := Repositories{
+		Users:         repository.NewUserRepository(db),
+		Organizations: repository.NewOrganizationRepository(db),
+		Members:       repository.NewOrganizationMemberRepository(db),
+		Preferences:   repository.NewUserPreferencesRepository(db),
+		Projects:      repository.NewProjectRepository(db),
+		Tasks:         repository.NewTaskRepository(db),
+		Exports:       repository.NewDataExportRepository(db),
+		AuditEvents:   repository.NewAuditEventRepository(db),
+		TaskActivity:  repository.NewTaskActivityRepository(db),
+	}
+
+	return NewWithRepositories(cfg, repos, db, handlers.NewHealthHandler(db, migrator, version), verifier, clerkUsers)
+}
+
+// NewWithRepositories builds the app on repos, with tx running their units of
+// work. Tests use it to run the app on the in-memory repositories
+func NewWithRepositories(
+	cfg *config.Config,
+	repos Repositories,
+	tx database.Transactor,
+	healthHandler *handlers.HealthHandler,
+	verifier middleware.TokenVerifier,
+	clerkUsers services.ClerkUsers,
+) *Server {
+	userRepo := repos.Users
+	orgRepo := repos.Organizations
+	memberRepo := repos.Members
+	prefsRepo := repos.Preferences
+	projectRepo := repos.Projects
+	taskRepo := repos.Tasks
+	exportRepo := repos.Exports
+	auditRepo := repos.AuditEvents
+	activityRepo := repos.TaskActivity
+
+	// Every change made through the repositories is audited
+	auditService := services.NewAuditService(auditRepo, userRepo, tx)
+	userRepo = auditService.Users(userRepo, memberRepo)
+	orgRepo = auditService.Organizations(orgRepo)
+	memberRepo = auditService.Members(memberRepo)
+	prefsRepo = auditService.Preferences(prefsRepo)
+	activityRepo = auditService.TaskActivity(activityRepo, taskRepo, projectRepo)
+	taskRepo = auditService.Tasks(taskRepo, projectRepo)
+	projectRepo = auditService.Projects(projectRepo)
+	exportRepo = auditService.Exports(exportRepo)
+
+	// Initialize services
+	exportService := services.NewExportService(userRepo, prefsRepo, orgRepo, memberRepo, taskRepo, exportRepo, auditRepo, activityRepo)
+	taskService := services.NewTaskService(taskRepo, projectRepo, memberRepo, activityRepo, tx)
+	erasureService := services.NewErasureService(userRepo, clerkUsers)
+
+	// Initialize handlers
+	webhookHandler := handlers.NewWebhookHandler(
+		userRepo,
+		orgRepo,
+		memberRepo,
+		tx,
+		cfg.ClerkWebhookSecret,
+	)
+	userHandler := handlers.NewUserHandler(userRepo, prefsRepo)
+	orgHandler := handlers.NewOrganizationHandler(userRepo, orgRepo, memberRepo, projectRepo, taskRepo, auditService)
+	exportHandler := handlers.NewDataExportHandler(userRepo, exportRepo, exportService)
+	taskHandler := handlers.NewTaskHandler(userRepo, memberRepo, projectRepo, taskRepo, taskService)
+	adminHandler := handlers.NewAdminHandler(erasureService)
+
+	allHandlers := &routes.Handlers{
+		Health:       healthHandler,
+		Webhook:      webhookHandler,
+		User:         userHandler,
+		Organization: orgHandler,
+		DataExport:   exportHandler,
+		Task:         taskHandler,
+		Admin:        adminHandler,
+	}
+
+	// Create Fiber app
+	app := fiber.New(fiber.Config{
+		ErrorHandler:    middleware.ErrorHandler,
+		StructValidator: validation.Default,
+		ReadTimeout:     cfg.ReadTimeout,
+		WriteTimeout:    cfg.WriteTimeout,
+		IdleTimeout:     cfg.IdleTimeout,
+	})
+
+	// Middleware
+	app.Use(recover.New())
+	app.Use(middleware.RequestID())
+	app.Use(middleware.ClientIP())
+	app.Use(middleware.Tracing())
+	app.Use(middleware.Metrics())
+	app.Use(middleware.Logger())
+	app.Use(cors.New(cors.Config{
+		AllowOrigins:     cfg.AllowedOrigins,
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", middleware.HeaderRequestID},
+		ExposeHeaders:    []string{middleware.HeaderRequestID},
+		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowCredentials: true,
+	}))
+
+	// Prometheus scrape endpoint, outside /api/v1 so scrapers need no token
+	app.Get("/metrics", adaptor.HTTPHandler(promhttp.HandlerFor(metrics.Registry, promhttp.HandlerOpts{})))
+
+	// Setup routes
+	routes.SetupRoutes(app, allHandlers, cfg, verifier)
+
+	return &Server{App: app, exportService: exportService}
+}
+
+// FailStaleExports fails exports left unfinished by a previous run, see
+// ExportService.FailStale
+func (s *Server) FailStaleExports(ctx context.Context) (int, error) {
+	return s.exportService.FailStale(ctx)
+}
+
+// Shutdown waits for the export workers to finish, cancelling them when ctx
+// ends first. Stop the app before calling it so no new exports are started
+func (s *Server) Shutdown(ctx context.Context) error {
+	return s.exportService.Shutdown(ctx)
+}
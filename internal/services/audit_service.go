@@ -0,0 +1,210 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/atavada/project-management-saas/internal/apperror"
+	"github.com/atavada/project-management-saas/internal/audit"
+	"github.com/atavada/project-management-saas/internal/database"
+	"github.com/atavada/project-management-saas/internal/listing"
+	"github.com/atavada/project-management-saas/internal/logging"
+	"github.com/atavada/project-management-saas/internal/models"
+	"github.com/atavada/project-management-saas/internal/repository"
+	"github.com/google/uuid"
+)
+
+// redactedValue stands in for personal data in audit changes
+const redactedValue = "[REDACTED]"
+
+// redactedFields hold personal data. The log records that they changed but
+// not their values. Anonymize clears what events keep of their actor, so
+// erasing a user leaves nothing of them in it
+var redactedFields = map[string]bool{
+	"clerk_user_id":       true,
+	"clerk_membership_id": true,
+	"email":               true,
+	"first_name":          true,
+	"last_name":           true,
+	"avatar_url":          true,
+	"display_name":        true,
+}
+
+// unauditedFields identify the record or change on every write
+var unauditedFields = map[string]bool{
+	"id":         true,
+	"created_at": true,
+	"updated_at": true,
+}
+
+// AuditService records who changed what. Repositories wrapped with Users,
+// Organizations and the other wrappers write an event for every change, in
+// the same transaction as the change
+type AuditService struct {
+	repo     repository.AuditEventRepository
+	userRepo repository.UserRepository
+	tx       database.Transactor
+}
+
+// NewAuditService takes the unwrapped user repository, it resolves user actors
+func NewAuditService(
+	repo repository.AuditEventRepository,
+	userRepo repository.UserRepository,
+	tx database.Transactor,
+) *AuditService {
+	return &AuditService{
+		repo:     repo,
+		userRepo: userRepo,
+		tx:       tx,
+	}
+}
+
+// AuditEntry is one change to record. Before is nil for created records and
+// After for deleted ones
+type AuditEntry struct {
+	OrganizationID *uuid.UUID
+	EntityType     models.AuditEntityType
+	EntityID       uuid.UUID
+	Action         models.AuditAction
+	Before         any
+	After          any
+}
+
+// Record writes an event for entry, attributed to the actor, client IP and
+// request ID carried by ctx. Updates that changed nothing are not recorded
+func (s *AuditService) Record(ctx context.Context, entry AuditEntry) error {
+	changes, err := diff(entry.Before, entry.After)
+	if err != nil {
+		return fmt.Errorf("error diffing %s %s: %w", entry.EntityType, entry.EntityID, err)
+	}
+	if entry.Action == models.AuditUpdated && len(changes) == 0 {
+		return nil
+	}
+
+	actor := audit.ActorFrom(ctx)
+	event := &models.AuditEvent{
+		OrganizationID: entry.OrganizationID,
+		ActorType:      actor.Type,
+		ActorName:      actor.Name,
+		EntityType:     entry.EntityType,
+		EntityID:       entry.EntityID,
+		Action:         entry.Action,
+		Changes:        changes,
+		IPAddress:      optional(audit.ClientIP(ctx)),
+		RequestID:      optional(logging.RequestID(ctx)),
+	}
+
+	// A user acting before their webhook arrived is kept by Clerk ID
+	if actor.Type == models.AuditActorUser {
+		user, err := s.userRepo.GetByClerkID(ctx, actor.ClerkUserID)
+		switch {
+		case errors.Is(err, apperror.ErrNotFound):
+			event.ActorName = actor.ClerkUserID
+		case err != nil:
+			return fmt.Errorf("error resolving audit actor: %w", err)
+		default:
+			event.ActorID = &user.ID
+		}
+	}
+
+	if _, err := s.repo.Create(ctx, event); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// List returns a page of the audit log of an organization
+func (s *AuditService) List(ctx context.Context, orgID uuid.UUID, params listing.Params) (*listing.Page[models.AuditEvent], error) {
+	return s.repo.ListForOrganization(ctx, orgID, params)
+}
+
+// track runs change and records the entry it returns in one transaction. A nil
+// entry means change made no change worth recording
+func (s *AuditService) track(ctx context.Context, change func(ctx context.Context) (*AuditEntry, error)) error {
+	return s.tx.WithTx(ctx, func(ctx context.Context) error {
+		entry, err := change(ctx)
+		if err != nil || entry == nil {
+			return err
+		}
+		return s.Record(ctx, *entry)
+	})
+}
+
+// diff compares the JSON fields of two records
+func diff(before, after any) (map[string]models.AuditChange, error) {
+	beforeFields, err := jsonFields(before)
+	if err != nil {
+		return nil, err
+	}
+	afterFields, err := jsonFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(map[string]models.AuditChange)
+	record := func(key string) {
+		if unauditedFields[key] {
+			return
+		}
+		if _, done := changes[key]; done {
+			return
+		}
+
+		oldValue, hadOld := beforeFields[key]
+		newValue, hasNew := afterFields[key]
+		if hadOld && hasNew && reflect.DeepEqual(oldValue, newValue) {
+			return
+		}
+
+		change := models.AuditChange{Before: oldValue, After: newValue}
+		if redactedFields[key] {
+			if hadOld {
+				change.Before = redactedValue
+			}
+			if hasNew {
+				change.After = redactedValue
+			}
+		}
+		changes[key] = change
+	}
+
+	for key := range beforeFields {
+		record(key)
+	}
+	for key := range afterFields {
+		record(key)
+	}
+
+	return changes, nil
+}
+
+// jsonFields decodes the JSON encoding of v into its top level fields, nil
+// for a nil record
+func jsonFields(v any) (map[string]any, error) {
+	if rv := reflect.ValueOf(v); !rv.IsValid() || (rv.Kind() == reflect.Pointer && rv.IsNil()) {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields map[string]any
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	return fields, nil
+}
+
+func optional(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
@@ -0,0 +1,532 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"github.com/atavada/project-management-saas/internal/apperror"
+	"github.com/atavada/project-management-saas/internal/models"
+	"github.com/atavada/project-management-saas/internal/repository"
+	"github.com/google/uuid"
+)
+
+// Users wraps repo so that its changes are audited. members is read to audit
+// the memberships removed when a user is anonymized
+func (s *AuditService) Users(repo repository.UserRepository, members repository.OrganizationMemberRepository) repository.UserRepository {
+	return &auditedUserRepository{UserRepository: repo, members: members, audit: s}
+}
+
+// Organizations wraps repo so that its changes are audited. Slug history is
+// covered by the slug change on the organization itself
+func (s *AuditService) Organizations(repo repository.OrganizationRepository) repository.OrganizationRepository {
+	return &auditedOrganizationRepository{OrganizationRepository: repo, audit: s}
+}
+
+// Members wraps repo so that its changes are audited
+func (s *AuditService) Members(repo repository.OrganizationMemberRepository) repository.OrganizationMemberRepository {
+	return &auditedMemberRepository{OrganizationMemberRepository: repo, audit: s}
+}
+
+// Preferences wraps repo so that its changes are audited
+func (s *AuditService) Preferences(repo repository.UserPreferencesRepository) repository.UserPreferencesRepository {
+	return &auditedPreferencesRepository{UserPreferencesRepository: repo, audit: s}
+}
+
+// Projects wraps repo so that its changes are audited
+func (s *AuditService) Projects(repo repository.ProjectRepository) repository.ProjectRepository {
+	return &auditedProjectRepository{ProjectRepository: repo, audit: s}
+}
+
+// Tasks wraps repo so that its changes are audited. projects finds the
+// organization of a task
+func (s *AuditService) Tasks(repo repository.TaskRepository, projects repository.ProjectRepository) repository.TaskRepository {
+	return &auditedTaskRepository{TaskRepository: repo, projects: projects, audit: s}
+}
+
+// TaskActivity wraps repo so that comments are audited. The other entries
+// record task changes, which are audited on the task. tasks and projects find
+// the organization of a comment
+func (s *AuditService) TaskActivity(
+	repo repository.TaskActivityRepository,
+	tasks repository.TaskRepository,
+	projects repository.ProjectRepository,
+) repository.TaskActivityRepository {
+	return &auditedTaskActivityRepository{TaskActivityRepository: repo, tasks: tasks, projects: projects, audit: s}
+}
+
+// Exports wraps repo so that its changes are audited
+func (s *AuditService) Exports(repo repository.DataExportRepository) repository.DataExportRepository {
+	return &auditedExportRepository{DataExportRepository: repo, audit: s}
+}
+
+type auditedUserRepository struct {
+	repository.UserRepository
+	members repository.OrganizationMemberRepository
+	audit   *AuditService
+}
+
+func (r *auditedUserRepository) Create(ctx context.Context, req *models.CreateUserRequest) (*models.User, error) {
+	var user *models.User
+	err := r.audit.track(ctx, func(ctx context.Context) (*AuditEntry, error) {
+		var err error
+		if user, err = r.UserRepository.Create(ctx, req); err != nil {
+			return nil, err
+		}
+		return &AuditEntry{EntityType: models.AuditEntityUser, EntityID: user.ID, Action: models.AuditCreated, After: user}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (r *auditedUserRepository) Upsert(ctx context.Context, req *models.CreateUserRequest) (*models.User, error) {
+	var user *models.User
+	err := r.audit.track(ctx, func(ctx context.Context) (*AuditEntry, error) {
+		before, err := existing(r.UserRepository.GetByClerkID(ctx, req.ClerkUserID))
+		if err != nil {
+			return nil, err
+		}
+		if user, err = r.UserRepository.Upsert(ctx, req); err != nil {
+			return nil, err
+		}
+		return &AuditEntry{EntityType: models.AuditEntityUser, EntityID: user.ID, Action: upsertAction(before), Before: before, After: user}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// Anonymize is recorded as deleting the user and each of their memberships,
+// the personal data it overwrites is redacted from the events
+func (r *auditedUserRepository) Anonymize(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	var user *models.User
+	err := r.audit.track(ctx, func(ctx context.Context) (*AuditEntry, error) {
+		before, err := r.UserRepository.GetByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		memberships, err := r.members.ListByUser(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if user, err = r.UserRepository.Anonymize(ctx, id); err != nil {
+			return nil, err
+		}
+
+		// The memberships go with the user, each organization logs its own
+		for _, member := range memberships {
+			if err := r.audit.Record(ctx, *memberEntry(models.AuditDeleted, &member, nil)); err != nil {
+				return nil, err
+			}
+		}
+
+		return &AuditEntry{EntityType: models.AuditEntityUser, EntityID: id, Action: models.AuditDeleted, Before: before}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+type auditedOrganizationRepository struct {
+	repository.OrganizationRepository
+	audit *AuditService
+}
+
+func (r *auditedOrganizationRepository) Create(ctx context.Context, req *models.CreateOrganizationRequest) (*models.Organization, error) {
+	var org *models.Organization
+	err := r.audit.track(ctx, func(ctx context.Context) (*AuditEntry, error) {
+		var err error
+		if org, err = r.OrganizationRepository.Create(ctx, req); err != nil {
+			return nil, err
+		}
+		return organizationEntry(models.AuditCreated, nil, org), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return org, nil
+}
+
+func (r *auditedOrganizationRepository) Upsert(ctx context.Context, req *models.CreateOrganizationRequest) (*models.Organization, error) {
+	var org *models.Organization
+	err := r.audit.track(ctx, func(ctx context.Context) (*AuditEntry, error) {
+		before, err := existing(r.OrganizationRepository.GetByClerkID(ctx, req.ClerkOrgID))
+		if err != nil {
+			return nil, err
+		}
+		if org, err = r.OrganizationRepository.Upsert(ctx, req); err != nil {
+			return nil, err
+		}
+		return organizationEntry(upsertAction(before), before, org), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return org, nil
+}
+
+func (r *auditedOrganizationRepository) Update(ctx context.Context, changed *models.Organization) (*models.Organization, error) {
+	var org *models.Organization
+	err := r.audit.track(ctx, func(ctx context.Context) (*AuditEntry, error) {
+		before, err := r.OrganizationRepository.GetByID(ctx, changed.ID)
+		if err != nil {
+			return nil, err
+		}
+		if org, err = r.OrganizationRepository.Update(ctx, changed); err != nil {
+			return nil, err
+		}
+		return organizationEntry(models.AuditUpdated, before, org), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return org, nil
+}
+
+func organizationEntry(action models.AuditAction, before, after *models.Organization) *AuditEntry {
+	return &AuditEntry{
+		OrganizationID: &after.ID,
+		EntityType:     models.AuditEntityOrganization,
+		EntityID:       after.ID,
+		Action:         action,
+		Before:         before,
+		After:          after,
+	}
+}
+
+type auditedMemberRepository struct {
+	repository.OrganizationMemberRepository
+	audit *AuditService
+}
+
+// Create records nothing when the membership already exists, the repository
+// leaves it as it is
+func (r *auditedMemberRepository) Create(ctx context.Context, member *models.OrganizationMember) error {
+	return r.audit.track(ctx, func(ctx context.Context) (*AuditEntry, error) {
+		before, err := existing(r.GetMember(ctx, member.OrganizationID, member.UserID))
+		if err != nil {
+			return nil, err
+		}
+		if err := r.OrganizationMemberRepository.Create(ctx, member); err != nil || before != nil {
+			return nil, err
+		}
+
+		after, err := r.GetMember(ctx, member.OrganizationID, member.UserID)
+		if err != nil {
+			return nil, err
+		}
+		return memberEntry(models.AuditCreated, nil, after), nil
+	})
+}
+
+func (r *auditedMemberRepository) Upsert(ctx context.Context, member *models.OrganizationMember) error {
+	return r.audit.track(ctx, func(ctx context.Context) (*AuditEntry, error) {
+		before, err := existing(r.GetMember(ctx, member.OrganizationID, member.UserID))
+		if err != nil {
+			return nil, err
+		}
+		if err := r.OrganizationMemberRepository.Upsert(ctx, member); err != nil {
+			return nil, err
+		}
+
+		after, err := r.GetMember(ctx, member.OrganizationID, member.UserID)
+		if err != nil {
+			return nil, err
+		}
+		return memberEntry(upsertAction(before), before, after), nil
+	})
+}
+
+func (r *auditedMemberRepository) Delete(ctx context.Context, orgID, userID uuid.UUID) error {
+	return r.audit.track(ctx, func(ctx context.Context) (*AuditEntry, error) {
+		before, err := existing(r.GetMember(ctx, orgID, userID))
+		if err != nil {
+			return nil, err
+		}
+		if err := r.OrganizationMemberRepository.Delete(ctx, orgID, userID); err != nil || before == nil {
+			return nil, err
+		}
+		return memberEntry(models.AuditDeleted, before, nil), nil
+	})
+}
+
+func (r *auditedMemberRepository) SetRole(ctx context.Context, orgID, userID uuid.UUID, role models.OrganizationRole) error {
+	return r.audit.track(ctx, func(ctx context.Context) (*AuditEntry, error) {
+		before, err := existing(r.GetMember(ctx, orgID, userID))
+		if err != nil {
+			return nil, err
+		}
+		if err := r.OrganizationMemberRepository.SetRole(ctx, orgID, userID, role); err != nil {
+			return nil, err
+		}
+
+		after, err := r.GetMember(ctx, orgID, userID)
+		if err != nil {
+			return nil, err
+		}
+		return memberEntry(upsertAction(before), before, after), nil
+	})
+}
+
+// memberEntry describes a change to a membership, one of before and after may
+// be nil
+func memberEntry(action models.AuditAction, before, after *models.OrganizationMember) *AuditEntry {
+	member := after
+	if member == nil {
+		member = before
+	}
+
+	return &AuditEntry{
+		OrganizationID: &member.OrganizationID,
+		EntityType:     models.AuditEntityOrganizationMember,
+		EntityID:       member.ID,
+		Action:         action,
+		Before:         before,
+		After:          after,
+	}
+}
+
+type auditedPreferencesRepository struct {
+	repository.UserPreferencesRepository
+	audit *AuditService
+}
+
+func (r *auditedPreferencesRepository) Upsert(ctx context.Context, prefs *models.UserPreferences) (*models.UserPreferences, error) {
+	var saved *models.UserPreferences
+	err := r.audit.track(ctx, func(ctx context.Context) (*AuditEntry, error) {
+		before, err := r.Get(ctx, prefs.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if saved, err = r.UserPreferencesRepository.Upsert(ctx, prefs); err != nil {
+			return nil, err
+		}
+		return &AuditEntry{
+			EntityType: models.AuditEntityUserPreferences,
+			EntityID:   saved.UserID,
+			Action:     upsertAction(before),
+			Before:     before,
+			After:      saved,
+		}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return saved, nil
+}
+
+type auditedProjectRepository struct {
+	repository.ProjectRepository
+	audit *AuditService
+}
+
+func (r *auditedProjectRepository) Create(ctx context.Context, req *models.CreateProjectRequest) (*models.Project, error) {
+	var project *models.Project
+	err := r.audit.track(ctx, func(ctx context.Context) (*AuditEntry, error) {
+		var err error
+		if project, err = r.ProjectRepository.Create(ctx, req); err != nil {
+			return nil, err
+		}
+		return &AuditEntry{
+			OrganizationID: &project.OrganizationID,
+			EntityType:     models.AuditEntityProject,
+			EntityID:       project.ID,
+			Action:         models.AuditCreated,
+			After:          project,
+		}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return project, nil
+}
+
+type auditedTaskRepository struct {
+	repository.TaskRepository
+	projects repository.ProjectRepository
+	audit    *AuditService
+}
+
+func (r *auditedTaskRepository) Create(ctx context.Context, req *models.CreateTaskRequest) (*models.Task, error) {
+	var task *models.Task
+	err := r.audit.track(ctx, func(ctx context.Context) (*AuditEntry, error) {
+		var err error
+		if task, err = r.TaskRepository.Create(ctx, req); err != nil {
+			return nil, err
+		}
+
+		return r.entry(ctx, models.AuditCreated, nil, task)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return task, nil
+}
+
+func (r *auditedTaskRepository) Update(ctx context.Context, changed *models.Task) (*models.Task, error) {
+	var task *models.Task
+	err := r.audit.track(ctx, func(ctx context.Context) (*AuditEntry, error) {
+		before, err := r.TaskRepository.GetByID(ctx, changed.ID)
+		if err != nil {
+			return nil, err
+		}
+		if task, err = r.TaskRepository.Update(ctx, changed); err != nil {
+			return nil, err
+		}
+		return r.entry(ctx, models.AuditUpdated, before, task)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return task, nil
+}
+
+// entry looks up the organization of the task through its project
+func (r *auditedTaskRepository) entry(ctx context.Context, action models.AuditAction, before, after *models.Task) (*AuditEntry, error) {
+	project, err := r.projects.GetByID(ctx, after.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AuditEntry{
+		OrganizationID: &project.OrganizationID,
+		EntityType:     models.AuditEntityTask,
+		EntityID:       after.ID,
+		Action:         action,
+		Before:         before,
+		After:          after,
+	}, nil
+}
+
+type auditedTaskActivityRepository struct {
+	repository.TaskActivityRepository
+	tasks    repository.TaskRepository
+	projects repository.ProjectRepository
+	audit    *AuditService
+}
+
+func (r *auditedTaskActivityRepository) Create(ctx context.Context, activity *models.TaskActivity) (*models.TaskActivity, error) {
+	if activity.Type != models.TaskActivityCommented {
+		return r.TaskActivityRepository.Create(ctx, activity)
+	}
+
+	var comment *models.TaskActivity
+	err := r.audit.track(ctx, func(ctx context.Context) (*AuditEntry, error) {
+		var err error
+		if comment, err = r.TaskActivityRepository.Create(ctx, activity); err != nil {
+			return nil, err
+		}
+
+		task, err := r.tasks.GetByID(ctx, comment.TaskID)
+		if err != nil {
+			return nil, err
+		}
+		project, err := r.projects.GetByID(ctx, task.ProjectID)
+		if err != nil {
+			return nil, err
+		}
+
+		// The actor is recorded on the event, not as part of the change
+		after := *comment
+		after.Actor = nil
+		return &AuditEntry{
+			OrganizationID: &project.OrganizationID,
+			EntityType:     models.AuditEntityTaskComment,
+			EntityID:       comment.ID,
+			Action:         models.AuditCreated,
+			After:          &after,
+		}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return comment, nil
+}
+
+type auditedExportRepository struct {
+	repository.DataExportRepository
+	audit *AuditService
+}
+
+func (r *auditedExportRepository) Create(ctx context.Context, userID uuid.UUID) (*models.DataExport, error) {
+	var export *models.DataExport
+	err := r.audit.track(ctx, func(ctx context.Context) (*AuditEntry, error) {
+		var err error
+		if export, err = r.DataExportRepository.Create(ctx, userID); err != nil {
+			return nil, err
+		}
+		return &AuditEntry{EntityType: models.AuditEntityDataExport, EntityID: export.ID, Action: models.AuditCreated, After: export}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return export, nil
+}
+
+func (r *auditedExportRepository) MarkProcessing(ctx context.Context, id uuid.UUID) error {
+	return r.update(ctx, id, func(ctx context.Context) error {
+		return r.DataExportRepository.MarkProcessing(ctx, id)
+	})
+}
+
+func (r *auditedExportRepository) Complete(ctx context.Context, id uuid.UUID, archive []byte) error {
+	return r.update(ctx, id, func(ctx context.Context) error {
+		return r.DataExportRepository.Complete(ctx, id, archive)
+	})
+}
+
+func (r *auditedExportRepository) Fail(ctx context.Context, id uuid.UUID, reason string) error {
+	return r.update(ctx, id, func(ctx context.Context) error {
+		return r.DataExportRepository.Fail(ctx, id, reason)
+	})
+}
+
+// update records a status change of an export made by change
+func (r *auditedExportRepository) update(ctx context.Context, id uuid.UUID, change func(ctx context.Context) error) error {
+	return r.audit.track(ctx, func(ctx context.Context) (*AuditEntry, error) {
+		before, err := r.GetByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if err := change(ctx); err != nil {
+			return nil, err
+		}
+
+		after, err := r.GetByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		return &AuditEntry{EntityType: models.AuditEntityDataExport, EntityID: id, Action: models.AuditUpdated, Before: before, After: after}, nil
+	})
+}
+
+// existing treats a record that is not found as one that doesn't exist yet
+func existing[T any](record *T, err error) (*T, error) {
+	if errors.Is(err, apperror.ErrNotFound) {
+		return nil, nil
+	}
+	return record, err
+}
+
+// upsertAction is created when there was no record before an upsert
+func upsertAction[T any](before *T) models.AuditAction {
+	if before == nil {
+		return models.AuditCreated
+	}
+	return models.AuditUpdated
+}
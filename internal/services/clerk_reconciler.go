@@ -0,0 +1,263 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/atavada/project-management-saas/internal/apperror"
+	"github.com/atavada/project-management-saas/internal/audit"
+	"github.com/atavada/project-management-saas/internal/database"
+	"github.com/atavada/project-management-saas/internal/models"
+	"github.com/atavada/project-management-saas/internal/repository"
+	clerk "github.com/clerk/clerk-sdk-go/v2"
+	"github.com/clerk/clerk-sdk-go/v2/organization"
+	"github.com/clerk/clerk-sdk-go/v2/organizationmembership"
+	"github.com/clerk/clerk-sdk-go/v2/user"
+	"github.com/google/uuid"
+)
+
+// clerkPageSize is the largest page the Clerk backend API allows
+const clerkPageSize int64 = 500
+
+// ReconcileResult counts what a reconciliation run touched
+type ReconcileResult struct {
+	Users              int
+	Organizations      int
+	Memberships        int
+	MembershipsRemoved int
+}
+
+// ClerkReconciler pulls users, organizations and memberships from the Clerk
+// API and brings the local tables in line, catching up on missed webhooks
+type ClerkReconciler struct {
+	userRepo   repository.UserRepository
+	orgRepo    repository.OrganizationRepository
+	memberRepo repository.OrganizationMemberRepository
+	tx         database.Transactor
+}
+
+func NewClerkReconciler(
+	clerkSecretKey string,
+	userRepo repository.UserRepository,
+	orgRepo repository.OrganizationRepository,
+	memberRepo repository.OrganizationMemberRepository,
+	tx database.Transactor,
+) *ClerkReconciler {
+	clerk.SetKey(clerkSecretKey)
+
+	return &ClerkReconciler{
+		userRepo:   userRepo,
+		orgRepo:    orgRepo,
+		memberRepo: memberRepo,
+		tx:         tx,
+	}
+}
+
+func (r *ClerkReconciler) Reconcile(ctx context.Context) (*ReconcileResult, error) {
+	result := &ReconcileResult{}
+	ctx = audit.WithActor(ctx, audit.ServiceAccount("clerk-reconciler"))
+
+	if err := r.reconcileUsers(ctx, result); err != nil {
+		return result, err
+	}
+
+	orgs, err := r.reconcileOrganizations(ctx, result)
+	if err != nil {
+		return result, err
+	}
+
+	for clerkOrgID, orgID := range orgs {
+		if err := r.reconcileMemberships(ctx, clerkOrgID, orgID, result); err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+func (r *ClerkReconciler) reconcileUsers(ctx context.Context, result *ReconcileResult) error {
+	for offset := int64(0); ; offset += clerkPageSize {
+		params := &user.ListParams{}
+		params.Limit = clerk.Int64(clerkPageSize)
+		params.Offset = clerk.Int64(offset)
+
+		list, err := user.List(ctx, params)
+		if err != nil {
+			return fmt.Errorf("error listing Clerk users: %w", err)
+		}
+
+		for _, u := range list.Users {
+			email := primaryEmail(u)
+			if email == "" {
+				slog.WarnContext(ctx, "Skipping Clerk user without email", "clerk_user_id", u.ID)
+				continue
+			}
+
+			_, err := r.userRepo.Upsert(ctx, &models.CreateUserRequest{
+				ClerkUserID: u.ID,
+				Email:       email,
+				FirstName:   stringValue(u.FirstName),
+				LastName:    stringValue(u.LastName),
+				AvatarURL:   stringValue(u.ImageURL),
+			})
+			if err != nil {
+				return err
+			}
+			result.Users++
+		}
+
+		if int64(len(list.Users)) < clerkPageSize {
+			return nil
+		}
+	}
+}
+
+// reconcileOrganizations upserts every Clerk organization and returns the local
+// ID of each one keyed by Clerk ID
+func (r *ClerkReconciler) reconcileOrganizations(ctx context.Context, result *ReconcileResult) (map[string]uuid.UUID, error) {
+	orgs := make(map[string]uuid.UUID)
+
+	for offset := int64(0); ; offset += clerkPageSize {
+		params := &organization.ListParams{}
+		params.Limit = clerk.Int64(clerkPageSize)
+		params.Offset = clerk.Int64(offset)
+
+		list, err := organization.List(ctx, params)
+		if err != nil {
+			return nil, fmt.Errorf("error listing Clerk organizations: %w", err)
+		}
+
+		for _, o := range list.Organizations {
+			org, err := r.syncOrganization(ctx, o)
+			if err != nil {
+				return nil, err
+			}
+
+			orgs[o.ID] = org.ID
+			result.Organizations++
+		}
+
+		if int64(len(list.Organizations)) < clerkPageSize {
+			return orgs, nil
+		}
+	}
+}
+
+// syncOrganization upserts one Clerk organization, recording a rename in the
+// same transaction
+func (r *ClerkReconciler) syncOrganization(ctx context.Context, o *clerk.Organization) (*models.Organization, error) {
+	var org *models.Organization
+	err := r.tx.WithTx(ctx, func(ctx context.Context) error {
+		existing, err := r.orgRepo.GetByClerkID(ctx, o.ID)
+		if err != nil && !errors.Is(err, apperror.ErrNotFound) {
+			return err
+		}
+
+		org, err = r.orgRepo.Upsert(ctx, &models.CreateOrganizationRequest{
+			ClerkOrgID: o.ID,
+			Name:       o.Name,
+			Slug:       o.Slug,
+			LogoURL:    stringValue(o.ImageURL),
+		})
+		if err != nil {
+			return err
+		}
+
+		if existing != nil && existing.Slug != org.Slug {
+			return r.orgRepo.RecordSlugChange(ctx, org.ID, existing.Slug)
+		}
+
+		return nil
+	})
+
+	return org, err
+}
+
+// reconcileMemberships adds memberships Clerk knows about, brings the roles of
+// existing ones in line and removes the ones it no longer has. Local owners are
+// kept, Clerk has no owner role
+func (r *ClerkReconciler) reconcileMemberships(ctx context.Context, clerkOrgID string, orgID uuid.UUID, result *ReconcileResult) error {
+	inClerk := make(map[uuid.UUID]bool)
+
+	for offset := int64(0); ; offset += clerkPageSize {
+		params := &organizationmembership.ListParams{OrganizationID: clerkOrgID}
+		params.Limit = clerk.Int64(clerkPageSize)
+		params.Offset = clerk.Int64(offset)
+
+		list, err := organizationmembership.List(ctx, params)
+		if err != nil {
+			return fmt.Errorf("error listing Clerk memberships of %s: %w", clerkOrgID, err)
+		}
+
+		for _, m := range list.OrganizationMemberships {
+			if m.PublicUserData == nil {
+				continue
+			}
+
+			u, err := r.userRepo.GetByClerkID(ctx, m.PublicUserData.UserID)
+			if errors.Is(err, apperror.ErrNotFound) {
+				slog.WarnContext(ctx, "User not found", "clerk_user_id", m.PublicUserData.UserID)
+				continue
+			}
+			if err != nil {
+				return err
+			}
+
+			err = r.memberRepo.Upsert(ctx, &models.OrganizationMember{
+				OrganizationID:    orgID,
+				UserID:            u.ID,
+				Role:              models.RoleFromClerk(m.Role),
+				ClerkMembershipID: m.ID,
+			})
+			if err != nil {
+				return err
+			}
+
+			inClerk[u.ID] = true
+			result.Memberships++
+		}
+
+		if int64(len(list.OrganizationMemberships)) < clerkPageSize {
+			break
+		}
+	}
+
+	members, err := r.memberRepo.ListByOrganization(ctx, orgID)
+	if err != nil {
+		return err
+	}
+
+	for _, member := range members {
+		if inClerk[member.UserID] || member.Role == models.RoleOwner {
+			continue
+		}
+		if err := r.memberRepo.Delete(ctx, orgID, member.UserID); err != nil {
+			return err
+		}
+		result.MembershipsRemoved++
+	}
+
+	return nil
+}
+
+// primaryEmail returns the user's primary email, falling back to the first one
+func primaryEmail(u *clerk.User) string {
+	for _, e := range u.EmailAddresses {
+		if u.PrimaryEmailAddressID != nil && e.ID == *u.PrimaryEmailAddressID {
+			return e.EmailAddress
+		}
+	}
+	if len(u.EmailAddresses) > 0 {
+		return u.EmailAddresses[0].EmailAddress
+	}
+	return ""
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,69 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/atavada/project-management-saas/internal/models"
+	"github.com/atavada/project-management-saas/internal/repository"
+	clerk "github.com/clerk/clerk-sdk-go/v2"
+	"github.com/clerk/clerk-sdk-go/v2/user"
+	"github.com/google/uuid"
+)
+
+// ClerkUsers deletes user accounts in Clerk
+type ClerkUsers interface {
+	// Delete removes the Clerk user, one that doesn't exist is not an error
+	Delete(ctx context.Context, clerkUserID string) error
+}
+
+type clerkUsers struct{}
+
+func NewClerkUsers(clerkSecretKey string) ClerkUsers {
+	clerk.SetKey(clerkSecretKey)
+
+	return clerkUsers{}
+}
+
+func (clerkUsers) Delete(ctx context.Context, clerkUserID string) error {
+	_, err := user.Delete(ctx, clerkUserID)
+
+	var apiErr *clerk.APIErrorResponse
+	if errors.As(err, &apiErr) && apiErr.HTTPStatusCode == http.StatusNotFound {
+		return nil
+	}
+	return err
+}
+
+// ErasureService answers erasure requests made outside of Clerk
+type ErasureService struct {
+	userRepo   repository.UserRepository
+	clerkUsers ClerkUsers
+}
+
+func NewErasureService(userRepo repository.UserRepository, clerkUsers ClerkUsers) *ErasureService {
+	return &ErasureService{
+		userRepo:   userRepo,
+		clerkUsers: clerkUsers,
+	}
+}
+
+// EraseUser deletes the user's Clerk account and anonymizes them locally.
+// Clerk goes first: a live account would sync the personal data back through
+// the next user.updated webhook or reconciliation, and the Clerk ID is gone
+// once the row is anonymized. If anonymizing fails, the user.deleted webhook
+// Clerk sends does it
+func (s *ErasureService) EraseUser(ctx context.Context, userID uuid.UUID) (*models.User, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.clerkUsers.Delete(ctx, user.ClerkUserID); err != nil {
+		return nil, fmt.Errorf("error deleting Clerk user: %w", err)
+	}
+
+	return s.userRepo.Anonymize(ctx, user.ID)
+}
@@ -0,0 +1,272 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/atavada/project-management-saas/internal/audit"
+	"github.com/atavada/project-management-saas/internal/logging"
+	"github.com/atavada/project-management-saas/internal/metrics"
+	"github.com/atavada/project-management-saas/internal/models"
+	"github.com/atavada/project-management-saas/internal/repository"
+	"github.com/google/uuid"
+)
+
+// exportTimeout bounds how long a single archive may take to build
+const exportTimeout = 5 * time.Minute
+
+// exportJob labels data export runs in the job metrics
+const exportJob = "data_export"
+
+// ExportService gathers everything stored about a user into a ZIP archive to
+// answer data subject access requests
+type ExportService struct {
+	userRepo     repository.UserRepository
+	prefsRepo    repository.UserPreferencesRepository
+	orgRepo      repository.OrganizationRepository
+	memberRepo   repository.OrganizationMemberRepository
+	taskRepo     repository.TaskRepository
+	exportRepo   repository.DataExportRepository
+	auditRepo    repository.AuditEventRepository
+	activityRepo repository.TaskActivityRepository
+
+	// workers tracks exports being built, ctx is cancelled when Shutdown gives up
+	// waiting on them
+	workers sync.WaitGroup
+	ctx     context.Context
+	cancel  context.CancelFunc
+}
+
+func NewExportService(
+	userRepo repository.UserRepository,
+	prefsRepo repository.UserPreferencesRepository,
+	orgRepo repository.OrganizationRepository,
+	memberRepo repository.OrganizationMemberRepository,
+	taskRepo repository.TaskRepository,
+	exportRepo repository.DataExportRepository,
+	auditRepo repository.AuditEventRepository,
+	activityRepo repository.TaskActivityRepository,
+) *ExportService {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &ExportService{
+		ctx:          ctx,
+		cancel:       cancel,
+		userRepo:     userRepo,
+		prefsRepo:    prefsRepo,
+		orgRepo:      orgRepo,
+		memberRepo:   memberRepo,
+		taskRepo:     taskRepo,
+		exportRepo:   exportRepo,
+		auditRepo:    auditRepo,
+		activityRepo: activityRepo,
+	}
+}
+
+// RequestExport records a pending export and builds it in the background
+func (s *ExportService) RequestExport(ctx context.Context, userID uuid.UUID) (*models.DataExport, error) {
+	export, err := s.exportRepo.Create(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.workers.Add(1)
+	go func() {
+		defer s.workers.Done()
+		s.process(export.ID, userID)
+	}()
+
+	return export, nil
+}
+
+// Shutdown waits for exports in progress to finish. When ctx expires first
+// they are cancelled and marked failed so they can be requested again
+func (s *ExportService) Shutdown(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.workers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		s.cancel()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// FailStale marks exports failed that are still pending or processing
+// exportTimeout after their last update. Their worker went down with the
+// process running it, so call this at startup to let them be requested again
+func (s *ExportService) FailStale(ctx context.Context) (int, error) {
+	exports, err := s.exportRepo.ListStale(ctx, time.Now().Add(-exportTimeout))
+	if err != nil {
+		return 0, err
+	}
+
+	ctx = audit.WithActor(ctx, audit.ServiceAccount("data-export-worker"))
+	for _, export := range exports {
+		if err := s.exportRepo.Fail(ctx, export.ID, "Export was interrupted"); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(exports), nil
+}
+
+func (s *ExportService) process(exportID, userID uuid.UUID) {
+	ctx, cancel := context.WithTimeout(s.ctx, exportTimeout)
+	defer cancel()
+
+	start := time.Now()
+	outcome := metrics.JobFailed
+	defer func() {
+		if outcome == metrics.JobFailed && errors.Is(s.ctx.Err(), context.Canceled) {
+			outcome = metrics.JobCancelled
+		}
+		metrics.Jobs.WithLabelValues(exportJob, outcome).Inc()
+		metrics.JobDuration.WithLabelValues(exportJob, outcome).Observe(time.Since(start).Seconds())
+	}()
+
+	ctx = logging.With(ctx, "export_id", exportID, "user_id", userID)
+	ctx = audit.WithActor(ctx, audit.ServiceAccount("data-export-worker"))
+
+	if err := s.exportRepo.MarkProcessing(ctx, exportID); err != nil {
+		slog.ErrorContext(ctx, "Error starting data export", "error", err)
+		s.fail(ctx, exportID, "Failed to start export")
+		return
+	}
+
+	archive, err := s.BuildArchive(ctx, userID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error building data export", "error", err)
+		s.fail(ctx, exportID, "Failed to build archive")
+		return
+	}
+
+	if err := s.exportRepo.Complete(ctx, exportID, archive); err != nil {
+		slog.ErrorContext(ctx, "Error completing data export", "error", err)
+		s.fail(ctx, exportID, "Failed to store archive")
+		return
+	}
+
+	outcome = metrics.JobSucceeded
+	slog.InfoContext(ctx, "Data export completed")
+}
+
+// fail marks an export failed so it can be requested again. ctx may be the
+// reason for the failure, so it is recorded with a fresh one
+func (s *ExportService) fail(ctx context.Context, exportID uuid.UUID, reason string) {
+	failCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+
+	if err := s.exportRepo.Fail(failCtx, exportID, reason); err != nil {
+		slog.ErrorContext(ctx, "Error failing data export", "error", err)
+	}
+}
+
+// BuildArchive collects the user's data and returns it as a ZIP archive with
+// one JSON document per section
+func (s *ExportService) BuildArchive(ctx context.Context, userID uuid.UUID) ([]byte, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	prefs, err := s.prefsRepo.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	organizations, err := s.orgRepo.GetUserOrganizations(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	memberships, err := s.memberRepo.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	tasksCreated, err := s.taskRepo.ListByCreator(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	tasksAssigned, err := s.taskRepo.ListByAssignee(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	activity, err := s.activityRepo.ListByActor(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Comments are the user's own writing, the rest of the history records
+	// changes they made to tasks
+	comments := make([]models.TaskActivity, 0)
+	taskActivity := make([]models.TaskActivity, 0)
+	for _, entry := range activity {
+		if entry.Type == models.TaskActivityCommented {
+			comments = append(comments, entry)
+		} else {
+			taskActivity = append(taskActivity, entry)
+		}
+	}
+
+	auditEvents, err := s.auditRepo.ListForUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	sections := []struct {
+		name string
+		data interface{}
+	}{
+		{"user.json", user},
+		{"preferences.json", prefs},
+		{"organizations.json", organizations},
+		{"memberships.json", memberships},
+		{"tasks_created.json", tasksCreated},
+		{"tasks_assigned.json", tasksAssigned},
+		{"comments.json", comments},
+		{"task_activity.json", taskActivity},
+		{"audit_events.json", auditEvents},
+		{"manifest.json", map[string]interface{}{
+			"user_id":      userID,
+			"generated_at": time.Now().UTC(),
+		}},
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, section := range sections {
+		w, err := zw.Create(section.name)
+		if err != nil {
+			return nil, fmt.Errorf("error creating %s: %w", section.name, err)
+		}
+
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(section.data); err != nil {
+			return nil, fmt.Errorf("error encoding %s: %w", section.name, err)
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("error closing archive: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,79 @@
+package services_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/atavada/project-management-saas/internal/models"
+	"github.com/atavada/project-management-saas/internal/repository/memory"
+	"github.com/atavada/project-management-saas/internal/services"
+	"github.com/google/uuid"
+)
+
+func TestFailStaleExports(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	exports := store.Exports()
+	userID := uuid.New()
+
+	// Left behind by a previous run an hour ago
+	store.Now = func() time.Time { return time.Now().Add(-time.Hour) }
+	pending, err := exports.Create(ctx, userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	processing, err := exports.Create(ctx, userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := exports.MarkProcessing(ctx, processing.ID); err != nil {
+		t.Fatal(err)
+	}
+	completed, err := exports.Create(ctx, userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := exports.Complete(ctx, completed.ID, []byte("archive")); err != nil {
+		t.Fatal(err)
+	}
+
+	// Still being built by another replica
+	store.Now = func() time.Time { return time.Now() }
+	recent, err := exports.Create(ctx, userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	service := services.NewExportService(store.Users(), store.Preferences(), store.Organizations(), store.Members(),
+		store.Tasks(), exports, store.AuditEvents(), store.TaskActivity())
+	failed, err := service.FailStale(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if failed != 2 {
+		t.Errorf("failed %d exports, want 2", failed)
+	}
+
+	tests := []struct {
+		name   string
+		id     uuid.UUID
+		status models.DataExportStatus
+	}{
+		{"stale pending", pending.ID, models.DataExportFailed},
+		{"stale processing", processing.ID, models.DataExportFailed},
+		{"completed", completed.ID, models.DataExportCompleted},
+		{"recent", recent.ID, models.DataExportPending},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			export, err := exports.GetByID(ctx, tt.id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if export.Status != tt.status {
+				t.Errorf("got %s, want %s", export.Status, tt.status)
+			}
+		})
+	}
+}
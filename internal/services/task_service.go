@@ -0,0 +1,198 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/atavada/project-management-saas/internal/apperror"
+	"github.com/atavada/project-management-saas/internal/database"
+	"github.com/atavada/project-management-saas/internal/listing"
+	"github.com/atavada/project-management-saas/internal/models"
+	"github.com/atavada/project-management-saas/internal/repository"
+	"github.com/google/uuid"
+)
+
+// TaskService changes tasks and keeps their history. Every status change,
+// reassignment, priority change, due date edit and comment is written to the
+// task's activity in the same transaction as the change
+type TaskService struct {
+	taskRepo     repository.TaskRepository
+	projectRepo  repository.ProjectRepository
+	memberRepo   repository.OrganizationMemberRepository
+	activityRepo repository.TaskActivityRepository
+	tx           database.Transactor
+}
+
+func NewTaskService(
+	taskRepo repository.TaskRepository,
+	projectRepo repository.ProjectRepository,
+	memberRepo repository.OrganizationMemberRepository,
+	activityRepo repository.TaskActivityRepository,
+	tx database.Transactor,
+) *TaskService {
+	return &TaskService{
+		taskRepo:     taskRepo,
+		projectRepo:  projectRepo,
+		memberRepo:   memberRepo,
+		activityRepo: activityRepo,
+		tx:           tx,
+	}
+}
+
+// Update applies a partial update made by actorID. A new assignee must be a
+// member of the task's organization
+func (s *TaskService) Update(ctx context.Context, taskID, actorID uuid.UUID, req *models.UpdateTaskRequest) (*models.Task, error) {
+	var task *models.Task
+	err := s.tx.WithTx(ctx, func(ctx context.Context) error {
+		// The row stays locked until the commit so concurrent edits apply one
+		// after the other and each records what it actually changed
+		before, err := s.taskRepo.GetByIDForUpdate(ctx, taskID)
+		if err != nil {
+			return err
+		}
+
+		changed := *before
+		if req.Title != nil {
+			changed.Title = *req.Title
+		}
+		if req.Description != nil {
+			changed.Description = *req.Description
+		}
+		if req.Status != nil {
+			changed.Status = *req.Status
+		}
+		if req.Priority != nil {
+			changed.Priority = *req.Priority
+		}
+		if req.AssignedTo.Set {
+			changed.AssignedTo = req.AssignedTo.Value
+		}
+		if req.DueDate.Set {
+			changed.DueDate = req.DueDate.Value
+		}
+
+		if changed.AssignedTo != nil && !equalValues(idValue(before.AssignedTo), idValue(changed.AssignedTo)) {
+			if err := s.checkAssignee(ctx, changed.ProjectID, *changed.AssignedTo); err != nil {
+				return err
+			}
+		}
+
+		if task, err = s.taskRepo.Update(ctx, &changed); err != nil {
+			return err
+		}
+
+		for _, activity := range taskChanges(before, task) {
+			activity.ActorID = &actorID
+			if _, err := s.activityRepo.Create(ctx, &activity); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return task, nil
+}
+
+// Comment adds a comment by actorID to the task's activity
+func (s *TaskService) Comment(ctx context.Context, taskID, actorID uuid.UUID, body string) (*models.TaskActivity, error) {
+	var comment *models.TaskActivity
+	err := s.tx.WithTx(ctx, func(ctx context.Context) error {
+		if _, err := s.taskRepo.GetByID(ctx, taskID); err != nil {
+			return err
+		}
+
+		var err error
+		comment, err = s.activityRepo.Create(ctx, &models.TaskActivity{
+			TaskID:  taskID,
+			ActorID: &actorID,
+			Type:    models.TaskActivityCommented,
+			Body:    &body,
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return comment, nil
+}
+
+// Activity returns a page of the history of a task, oldest first by default
+func (s *TaskService) Activity(ctx context.Context, taskID uuid.UUID, params listing.Params) (*listing.Page[models.TaskActivity], error) {
+	return s.activityRepo.ListForTask(ctx, taskID, params)
+}
+
+// checkAssignee rejects assignees outside the organization of the project
+func (s *TaskService) checkAssignee(ctx context.Context, projectID, userID uuid.UUID) error {
+	project, err := s.projectRepo.GetByID(ctx, projectID)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.memberRepo.GetMember(ctx, project.OrganizationID, userID)
+	if errors.Is(err, apperror.ErrNotFound) {
+		return apperror.Validation("Request validation failed").WithFields([]apperror.FieldError{{
+			Field:   "assigned_to",
+			Rule:    "member",
+			Message: "must be a member of the organization",
+		}})
+	}
+
+	return err
+}
+
+// taskChanges lists the activity entries for the fields that differ between
+// before and after. Title and description edits are not part of the history
+func taskChanges(before, after *models.Task) []models.TaskActivity {
+	fields := []struct {
+		activity models.TaskActivityType
+		from, to *string
+	}{
+		{models.TaskActivityStatusChanged, optional(string(before.Status)), optional(string(after.Status))},
+		{models.TaskActivityReassigned, idValue(before.AssignedTo), idValue(after.AssignedTo)},
+		{models.TaskActivityPriorityChanged, optional(string(before.Priority)), optional(string(after.Priority))},
+		{models.TaskActivityDueDateChanged, dateValue(before.DueDate), dateValue(after.DueDate)},
+	}
+
+	var activities []models.TaskActivity
+	for _, field := range fields {
+		if equalValues(field.from, field.to) {
+			continue
+		}
+		activities = append(activities, models.TaskActivity{
+			TaskID: after.ID,
+			Type:   field.activity,
+			From:   field.from,
+			To:     field.to,
+		})
+	}
+
+	return activities
+}
+
+func idValue(id *uuid.UUID) *string {
+	if id == nil {
+		return nil
+	}
+	return optional(id.String())
+}
+
+// dateValue formats due dates the way the DATE column stores them
+func dateValue(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+	return optional(t.Format(time.DateOnly))
+}
+
+func equalValues(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
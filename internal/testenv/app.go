@@ -0,0 +1,183 @@
+package testenv
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/atavada/project-management-saas/internal/config"
+	"github.com/atavada/project-management-saas/internal/database"
+	"github.com/atavada/project-management-saas/internal/handlers"
+	"github.com/atavada/project-management-saas/internal/repository/memory"
+	"github.com/atavada/project-management-saas/internal/server"
+	"github.com/gofiber/fiber/v3"
+)
+
+// AdminUserID is the Clerk user the test app lets through the platform admin
+// routes
+const AdminUserID = "user_admin"
+
+// requestTimeout bounds a request sent through Do, generous enough for a
+// cold database
+const requestTimeout = 10 * time.Second
+
+// Env is the full API running on its own database, or on the in-memory
+// repositories for NewMemory
+type Env struct {
+	App      *fiber.App
+	DB       *database.DB
+	Store    *memory.Store
+	Config   *config.Config
+	Verifier *Verifier
+	Clerk    *ClerkUsers
+	Webhooks *Webhooks
+}
+
+// New starts the app on a fresh database, see NewDatabase. The export workers
+// are stopped when the test ends
+func New(t testing.TB) *Env {
+	t.Helper()
+
+	db, migrator := NewDatabase(t)
+
+	cfg := newConfig()
+	verifier := NewVerifier()
+	clerkUsers := &ClerkUsers{}
+	srv := server.New(cfg, db, migrator, verifier, clerkUsers, "test")
+	stopOnCleanup(t, srv, cfg)
+
+	return &Env{
+		App:      srv.App,
+		DB:       db,
+		Config:   cfg,
+		Verifier: verifier,
+		Clerk:    clerkUsers,
+		Webhooks: NewWebhooks(WebhookSecret),
+	}
+}
+
+// NewMemory starts the app on the in-memory repositories, for tests of
+// handlers, webhooks and authorization that need no Postgres. DB is nil, the
+// readiness probe is not served
+func NewMemory(t testing.TB) *Env {
+	t.Helper()
+
+	store := memory.NewStore()
+	repos := server.Repositories{
+		Users:         store.Users(),
+		Organizations: store.Organizations(),
+		Members:       store.Members(),
+		Preferences:   store.Preferences(),
+		Projects:      store.Projects(),
+		Tasks:         store.Tasks(),
+		Exports:       store.Exports(),
+		AuditEvents:   store.AuditEvents(),
+		TaskActivity:  store.TaskActivity(),
+	}
+
+	cfg := newConfig()
+	verifier := NewVerifier()
+	clerkUsers := &ClerkUsers{}
+	srv := server.NewWithRepositories(cfg, repos, store, handlers.NewHealthHandler(nil, nil, "test"), verifier, clerkUsers)
+	stopOnCleanup(t, srv, cfg)
+
+	return &Env{
+		App:      srv.App,
+		Store:    store,
+		Config:   cfg,
+		Verifier: verifier,
+		Clerk:    clerkUsers,
+		Webhooks: NewWebhooks(WebhookSecret),
+	}
+}
+
+func newConfig() *config.Config {
+	cfg := config.Defaults()
+	cfg.Environment = config.Test
+	cfg.ClerkWebhookSecret = WebhookSecret
+	cfg.AdminUserIDs = []string{AdminUserID}
+
+	return cfg
+}
+
+// stopOnCleanup stops the export workers of srv when the test ends
+func stopOnCleanup(t testing.TB, srv *server.Server, cfg *config.Config) {
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+		defer cancel()
+		if err := srv.Shutdown(ctx); err != nil {
+			t.Errorf("error stopping export workers: %v", err)
+		}
+	})
+}
+
+// Response is a response with its body read
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// Decode unmarshals the JSON body into v
+func (r *Response) Decode(t testing.TB, v any) {
+	t.Helper()
+
+	if err := json.Unmarshal(r.Body, v); err != nil {
+		t.Fatalf("error decoding response %s: %v", r.Body, err)
+	}
+}
+
+// Do sends a request as the Clerk user, or without a token when clerkUserID is
+// empty. A non-nil body is sent as JSON
+func (e *Env) Do(t testing.TB, method, path, clerkUserID string, body any) *Response {
+	t.Helper()
+
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			t.Fatalf("error encoding request body: %v", err)
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if clerkUserID != "" {
+		req.Header.Set("Authorization", "Bearer "+e.Verifier.Token(clerkUserID))
+	}
+
+	return e.Send(t, req)
+}
+
+// Deliver sends a webhook to the app
+func (e *Env) Deliver(t testing.TB, webhook *Webhook) *Response {
+	t.Helper()
+
+	return e.Send(t, webhook.Request(t))
+}
+
+// Send runs req through the app and reads the response
+func (e *Env) Send(t testing.TB, req *http.Request) *Response {
+	t.Helper()
+
+	resp, err := e.App.Test(req, fiber.TestConfig{Timeout: requestTimeout})
+	if err != nil {
+		t.Fatalf("error sending %s %s: %v", req.Method, req.URL.Path, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("error reading response body: %v", err)
+	}
+
+	return &Response{StatusCode: resp.StatusCode, Header: resp.Header, Body: body}
+}
@@ -0,0 +1,76 @@
+package testenv
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/atavada/project-management-saas/internal/middleware"
+	"github.com/atavada/project-management-saas/internal/services"
+)
+
+// Verifier stands in for Clerk: it accepts the tokens it issued and rejects
+// everything else
+type Verifier struct {
+	mu     sync.Mutex
+	tokens map[string]middleware.Claims
+}
+
+var _ middleware.TokenVerifier = (*Verifier)(nil)
+
+func NewVerifier() *Verifier {
+	return &Verifier{tokens: make(map[string]middleware.Claims)}
+}
+
+// Token issues a token for the Clerk user with no active organization
+func (v *Verifier) Token(clerkUserID string) string {
+	return v.TokenFor(middleware.Claims{UserID: clerkUserID})
+}
+
+// TokenFor issues a token carrying claims
+func (v *Verifier) TokenFor(claims middleware.Claims) string {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	token := "test_" + randomHex(16)
+	v.tokens[token] = claims
+
+	return token
+}
+
+func (v *Verifier) Verify(ctx context.Context, token string) (*middleware.Claims, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	claims, ok := v.tokens[token]
+	if !ok {
+		return nil, errors.New("unknown test token")
+	}
+
+	return &claims, nil
+}
+
+// ClerkUsers stands in for the Clerk user API and remembers which users were
+// deleted
+type ClerkUsers struct {
+	mu      sync.Mutex
+	deleted []string
+}
+
+var _ services.ClerkUsers = (*ClerkUsers)(nil)
+
+func (u *ClerkUsers) Delete(ctx context.Context, clerkUserID string) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.deleted = append(u.deleted, clerkUserID)
+	return nil
+}
+
+// Deleted returns the Clerk IDs of the deleted users, in order
+func (u *ClerkUsers) Deleted() []string {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	return append([]string(nil), u.deleted...)
+}
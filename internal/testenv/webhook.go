@@ -0,0 +1,134 @@
+package testenv
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	svix "github.com/svix/svix-webhooks/go"
+)
+
+// WebhookSecret is the Svix signing secret the test app is configured with
+const WebhookSecret = "whsec_dGVzdC13ZWJob29rLXNpZ25pbmctc2VjcmV0"
+
+// WebhookPath is where Clerk delivers webhooks
+const WebhookPath = "/api/v1/webhooks/clerk"
+
+// Webhooks builds Clerk webhook deliveries signed the way Svix signs them
+type Webhooks struct {
+	secret string
+}
+
+func NewWebhooks(secret string) *Webhooks {
+	return &Webhooks{secret: secret}
+}
+
+// Webhook is one delivery, its fields can be changed before Request signs it
+type Webhook struct {
+	ID        string
+	Type      string
+	Data      any
+	Timestamp time.Time
+
+	secret string
+}
+
+// Event starts a delivery of a Clerk event with a fresh message ID
+func (w *Webhooks) Event(eventType string, data any) *Webhook {
+	return &Webhook{
+		ID:        "msg_" + randomHex(12),
+		Type:      eventType,
+		Data:      data,
+		Timestamp: time.Now(),
+		secret:    w.secret,
+	}
+}
+
+// SignedWith signs the delivery with another secret, to test rejected signatures
+func (wh *Webhook) SignedWith(secret string) *Webhook {
+	wh.secret = secret
+	return wh
+}
+
+// At sets the signed timestamp, Svix rejects deliveries more than five minutes
+// away from now
+func (wh *Webhook) At(timestamp time.Time) *Webhook {
+	wh.Timestamp = timestamp
+	return wh
+}
+
+// Request encodes and signs the delivery as a POST to WebhookPath
+func (wh *Webhook) Request(t testing.TB) *http.Request {
+	t.Helper()
+
+	payload, err := json.Marshal(map[string]any{
+		"object": "event",
+		"type":   wh.Type,
+		"data":   wh.Data,
+	})
+	if err != nil {
+		t.Fatalf("error encoding webhook payload: %v", err)
+	}
+
+	signer, err := svix.NewWebhook(wh.secret)
+	if err != nil {
+		t.Fatalf("error creating webhook signer: %v", err)
+	}
+	signature, err := signer.Sign(wh.ID, wh.Timestamp, payload)
+	if err != nil {
+		t.Fatalf("error signing webhook: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, WebhookPath, bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("svix-id", wh.ID)
+	req.Header.Set("svix-timestamp", strconv.FormatInt(wh.Timestamp.Unix(), 10))
+	req.Header.Set("svix-signature", signature)
+
+	return req
+}
+
+// UserData is the data of a user.created or user.updated event
+func UserData(clerkUserID, email, firstName, lastName string) map[string]any {
+	return map[string]any{
+		"id":         clerkUserID,
+		"first_name": firstName,
+		"last_name":  lastName,
+		"image_url":  "",
+		"email_addresses": []any{
+			map[string]any{"email_address": email},
+		},
+	}
+}
+
+// DeletedUserData is the data of a user.deleted event
+func DeletedUserData(clerkUserID string) map[string]any {
+	return map[string]any{"id": clerkUserID, "deleted": true}
+}
+
+// OrganizationData is the data of an organization.created or
+// organization.updated event. createdBy is the Clerk ID of the creator
+func OrganizationData(clerkOrgID, name, slug, createdBy string) map[string]any {
+	return map[string]any{
+		"id":         clerkOrgID,
+		"name":       name,
+		"slug":       slug,
+		"image_url":  "",
+		"created_by": createdBy,
+	}
+}
+
+// MembershipData is the data of an organizationMembership event. role is the
+// Clerk role, like org:admin or org:member
+func MembershipData(clerkMembershipID, clerkOrgID, clerkUserID, role string) map[string]any {
+	return map[string]any{
+		"id":               clerkMembershipID,
+		"role":             role,
+		"organization":     map[string]any{"id": clerkOrgID},
+		"public_user_data": map[string]any{"user_id": clerkUserID},
+	}
+}
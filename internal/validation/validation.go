@@ -0,0 +1,142 @@
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/atavada/project-management-saas/internal/apperror"
+	"github.com/go-playground/validator/v10"
+)
+
+// localePattern accepts BCP 47 style tags such as "en", "pt-BR" or "zh-Hant-TW"
+var localePattern = regexp.MustCompile(`^[a-zA-Z]{2,3}(-[a-zA-Z0-9]{2,8})*$`)
+
+// enum is implemented by the string enums in models
+type enum interface {
+	IsValid() bool
+}
+
+// Validator checks structs against their validate tags. Failures are returned
+// as an apperror validation error listing every invalid field by its JSON name
+type Validator struct {
+	validate *validator.Validate
+}
+
+// Default is used by the Fiber binder and by code validating structs that don't
+// come from a request body, like those built from webhook payloads
+var Default = New()
+
+func New() *Validator {
+	validate := validator.New(validator.WithRequiredStructEnabled())
+
+	// Report fields by the name clients send them as
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		for _, tag := range []string{"json", "query", "params"} {
+			name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+			if name == "-" {
+				return ""
+			}
+			if name != "" {
+				return name
+			}
+		}
+		return field.Name
+	})
+
+	// locale: a BCP 47 language tag
+	validate.RegisterValidation("locale", func(fl validator.FieldLevel) bool {
+		locale := fl.Field().String()
+		return len(locale) <= 35 && localePattern.MatchString(locale)
+	})
+
+	// enum: one of the values of a models enum type
+	validate.RegisterValidation("enum", func(fl validator.FieldLevel) bool {
+		value, ok := fl.Field().Interface().(enum)
+		return ok && value.IsValid()
+	})
+
+	return &Validator{validate: validate}
+}
+
+// Validate implements fiber.StructValidator, so c.Bind() validates what it binds
+func (v *Validator) Validate(out any) error {
+	return v.Struct(out)
+}
+
+// Struct validates s, returning nil or a validation *apperror.Error
+func (v *Validator) Struct(s any) error {
+	err := v.validate.Struct(s)
+	if err == nil {
+		return nil
+	}
+
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		return apperror.Internal("Failed to validate request", err)
+	}
+
+	fields := make([]apperror.FieldError, 0, len(validationErrs))
+	for _, fe := range validationErrs {
+		fields = append(fields, apperror.FieldError{
+			Field:   fieldPath(fe),
+			Rule:    fe.Tag(),
+			Message: message(fe),
+		})
+	}
+
+	return apperror.Validation("Request validation failed").WithFields(fields)
+}
+
+// Struct validates s with the Default validator
+func Struct(s any) error {
+	return Default.Struct(s)
+}
+
+// fieldPath drops the top level struct name, e.g. "settings.timezone"
+func fieldPath(fe validator.FieldError) string {
+	_, path, found := strings.Cut(fe.Namespace(), ".")
+	if !found {
+		return fe.Field()
+	}
+	return path
+}
+
+func message(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "uuid", "uuid4":
+		return "must be a valid UUID"
+	case "timezone":
+		return "must be an IANA time zone, e.g. Europe/Berlin"
+	case "locale":
+		return "must be a language tag, e.g. en or pt-BR"
+	case "enum", "oneof":
+		return fmt.Sprintf("has an unsupported value %q", fmt.Sprint(fe.Value()))
+	case "unique":
+		return "must not contain duplicates"
+	case "min":
+		if fe.Kind() == reflect.Slice {
+			return fmt.Sprintf("must contain at least %s items", fe.Param())
+		}
+		if fe.Kind() == reflect.String {
+			return fmt.Sprintf("must be at least %s characters", fe.Param())
+		}
+		return fmt.Sprintf("must be at least %s", fe.Param())
+	case "max":
+		if fe.Kind() == reflect.Slice {
+			return fmt.Sprintf("must contain at most %s items", fe.Param())
+		}
+		if fe.Kind() == reflect.String {
+			return fmt.Sprintf("must be at most %s characters", fe.Param())
+		}
+		return fmt.Sprintf("must be at most %s", fe.Param())
+	default:
+		return fmt.Sprintf("failed the %s rule", fe.Tag())
+	}
+}